@@ -0,0 +1,14 @@
+package cfapi
+
+// CCDeploymentRequest is the payload for POST /v3/deployments.
+type CCDeploymentRequest struct {
+	Strategy     string
+	MaxInFlight  *int
+	CanaryWeight *int
+}
+
+// CCDeployment is the relevant subset of a /v3/deployments/{guid} response.
+type CCDeployment struct {
+	ID     string
+	Status string
+}
@@ -0,0 +1,232 @@
+package cfapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// dockerConfigJSON is the subset of a standard ~/.docker/config.json this
+// provider understands: per-registry basic auth, so users can reuse
+// existing registry credentials without flattening them into
+// username/password fields.
+type dockerConfigJSON struct {
+	Auths map[string]struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Auth     string `json:"auth"`
+	} `json:"auths"`
+}
+
+// registryAuth picks username/password out of docker_credentials, accepting
+// either explicit "username"/"password" keys or a "config_json" key holding
+// a full ~/.docker/config.json document.
+func registryAuth(image string, credentials map[string]interface{}) (username, password string) {
+	if credentials == nil {
+		return "", ""
+	}
+	if u, ok := credentials["username"].(string); ok {
+		username = u
+	}
+	if p, ok := credentials["password"].(string); ok {
+		password = p
+	}
+	if username != "" || password != "" {
+		return username, password
+	}
+
+	configJSON, ok := credentials["config_json"].(string)
+	if !ok || len(configJSON) == 0 {
+		return "", ""
+	}
+	var cfg dockerConfigJSON
+	if err := json.Unmarshal([]byte(configJSON), &cfg); err != nil {
+		return "", ""
+	}
+	registry := image
+	if idx := strings.Index(registry, "/"); idx >= 0 {
+		registry = registry[:idx]
+	} else {
+		registry = "index.docker.io"
+	}
+	if auth, ok := cfg.Auths[registry]; ok {
+		if len(auth.Username) == 0 && len(auth.Auth) > 0 {
+			// "auth" holds base64("username:password")
+			if decoded, err := base64.StdEncoding.DecodeString(auth.Auth); err == nil {
+				if parts := strings.SplitN(string(decoded), ":", 2); len(parts) == 2 {
+					return parts[0], parts[1]
+				}
+			}
+		}
+		return auth.Username, auth.Password
+	}
+	return "", ""
+}
+
+// HeadRegistryManifestDigest issues a HEAD /v2/<name>/manifests/<ref>
+// request against the image's registry and returns the resolved
+// "sha256:..." digest reported in the Docker-Content-Digest header. This
+// lets the provider pin a mutable tag to an immutable digest at plan time
+// so drift is detected when the remote tag is moved.
+func HeadRegistryManifestDigest(image string, credentials map[string]interface{}) (string, error) {
+
+	registry, name, ref := splitImageRef(image)
+	username, password := registryAuth(image, credentials)
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", registry, name, ref)
+
+	resp, err := headManifest(manifestURL, username, password, "")
+	if err != nil {
+		return "", err
+	}
+
+	// Docker Hub, and most v2 registries, challenge every unauthenticated
+	// request -- even for anonymous/public pulls -- with a 401 and a
+	// Www-Authenticate: Bearer header naming a token endpoint to retry
+	// against. Basic auth alone (above) only ever satisfies registries
+	// that skip this challenge.
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("Www-Authenticate")
+		resp.Body.Close()
+
+		realm, service, scope, ok := parseBearerChallenge(challenge)
+		if !ok {
+			return "", fmt.Errorf("registry returned status code: %d", http.StatusUnauthorized)
+		}
+		if len(scope) == 0 {
+			scope = fmt.Sprintf("repository:%s:pull", name)
+		}
+
+		token, err := fetchBearerToken(realm, service, scope, username, password)
+		if err != nil {
+			return "", err
+		}
+
+		resp, err = headManifest(manifestURL, "", "", token)
+		if err != nil {
+			return "", err
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry returned status code: %d", resp.StatusCode)
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if len(digest) == 0 {
+		return "", fmt.Errorf("registry response did not include a Docker-Content-Digest header")
+	}
+	return digest, nil
+}
+
+// headManifest issues the actual HEAD request, authenticating with token
+// (if set) or with username/password (if token is empty and username is
+// set), or anonymously otherwise.
+func headManifest(url, username, password, token string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.docker.distribution.manifest.v2+json")
+	if len(token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if len(username) > 0 {
+		req.SetBasicAuth(username, password)
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// parseBearerChallenge parses a Www-Authenticate header of the form
+// `Bearer realm="...",service="...",scope="..."`, as returned by Docker
+// Hub and most v2 registries on the initial unauthenticated request.
+func parseBearerChallenge(header string) (realm, service, scope string, ok bool) {
+	if !strings.HasPrefix(header, "Bearer ") {
+		return "", "", "", false
+	}
+	params := map[string]string{}
+	for _, part := range strings.Split(strings.TrimPrefix(header, "Bearer "), ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		params[kv[0]] = strings.Trim(kv[1], `"`)
+	}
+	realm, ok = params["realm"]
+	if !ok || len(realm) == 0 {
+		return "", "", "", false
+	}
+	return realm, params["service"], params["scope"], true
+}
+
+// fetchBearerToken exchanges a registry's Bearer challenge for a token by
+// calling its token realm, passing along username/password so private
+// scopes also resolve for credentialed pulls.
+func fetchBearerToken(realm, service, scope, username, password string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, realm, nil)
+	if err != nil {
+		return "", err
+	}
+	q := req.URL.Query()
+	if len(service) > 0 {
+		q.Set("service", service)
+	}
+	if len(scope) > 0 {
+		q.Set("scope", scope)
+	}
+	req.URL.RawQuery = q.Encode()
+	if len(username) > 0 {
+		req.SetBasicAuth(username, password)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry token endpoint returned status code: %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if len(body.Token) > 0 {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// splitImageRef splits "registry/name:tag" (registry defaulting to Docker
+// Hub and tag defaulting to "latest") into its three parts.
+func splitImageRef(image string) (registry, name, ref string) {
+	registry = "index.docker.io"
+	rest := image
+
+	if idx := strings.Index(rest, "/"); idx >= 0 && strings.ContainsAny(rest[:idx], ".:") {
+		registry = rest[:idx]
+		rest = rest[idx+1:]
+	}
+
+	ref = "latest"
+	if idx := strings.LastIndex(rest, ":"); idx >= 0 {
+		name = rest[:idx]
+		ref = rest[idx+1:]
+	} else {
+		name = rest
+	}
+
+	// Docker Hub has no top-level namespace: an unqualified official image
+	// name like "nginx" actually lives at "library/nginx".
+	if registry == "index.docker.io" && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+
+	return registry, name, ref
+}
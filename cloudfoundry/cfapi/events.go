@@ -0,0 +1,142 @@
+package cfapi
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// CCEvent is a structured subset of a CF v3 app event or v2 audit event,
+// normalized enough to log or attach to an error regardless of which feed
+// it came from.
+type CCEvent struct {
+	Type      string
+	Actor     string
+	Message   string
+	Timestamp time.Time
+}
+
+func (e CCEvent) String() string {
+	return fmt.Sprintf("[%s] %s: %s", e.Timestamp.Format(time.RFC3339), e.Type, e.Message)
+}
+
+// terminalEventTypes are events that indicate the operation being watched
+// has failed outright, as opposed to routine progress notices.
+var terminalEventTypes = map[string]bool{
+	"app.crash":             true,
+	"audit.app.process.crash": true,
+	"app.staging.failed":    true,
+	"app.process.crash":     true,
+}
+
+// AppEventStream polls the CF v3 app events feed (and the v2 audit events
+// feed for the same actee, which still carries some event types v3 does
+// not) and delivers them on Events in receipt order. It keeps a bounded
+// history so a caller can attach the last N events to an error without
+// holding its own buffer.
+type AppEventStream struct {
+	Events chan CCEvent
+
+	am     *AppManager
+	appID  string
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	history []CCEvent
+}
+
+// maxEventHistory bounds how many events StreamAppEvents retains for Recent.
+const maxEventHistory = 50
+
+// StreamAppEvents opens an event feed for appID and begins polling it in
+// the background. The stream stops, closing Events, when ctx is done or
+// Close is called.
+func (am *AppManager) StreamAppEvents(ctx context.Context, appID string) (*AppEventStream, error) {
+
+	streamCtx, cancel := context.WithCancel(ctx)
+
+	s := &AppEventStream{
+		Events: make(chan CCEvent, 32),
+		am:     am,
+		appID:  appID,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go s.poll(streamCtx)
+
+	return s, nil
+}
+
+func (s *AppEventStream) poll(ctx context.Context) {
+	defer close(s.done)
+	defer close(s.Events)
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	var since time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		v3Events, err := s.am.ReadAppEventsV3(s.appID, since)
+		if err == nil {
+			for _, e := range v3Events {
+				s.emit(e)
+				since = e.Timestamp
+			}
+		}
+
+		v2Events, err := s.am.ReadAppEventsV2(s.appID, since)
+		if err == nil {
+			for _, e := range v2Events {
+				s.emit(e)
+				since = e.Timestamp
+			}
+		}
+	}
+}
+
+func (s *AppEventStream) emit(e CCEvent) {
+	s.history = append(s.history, e)
+	if len(s.history) > maxEventHistory {
+		s.history = s.history[len(s.history)-maxEventHistory:]
+	}
+	select {
+	case s.Events <- e:
+	default:
+		// Events is a bounded buffer; a slow consumer should not block
+		// polling, it can still see the event in Recent().
+	}
+}
+
+// Recent returns the last n events observed (fewer if the stream has not
+// seen n yet), oldest first.
+func (s *AppEventStream) Recent(n int) []CCEvent {
+	if n >= len(s.history) {
+		return s.history
+	}
+	return s.history[len(s.history)-n:]
+}
+
+// Failures returns the subset of Recent(n) that indicate the watched
+// operation failed outright (crash, staging failure, etc).
+func (s *AppEventStream) Failures(n int) []CCEvent {
+	var failures []CCEvent
+	for _, e := range s.Recent(n) {
+		if terminalEventTypes[e.Type] {
+			failures = append(failures, e)
+		}
+	}
+	return failures
+}
+
+// Close stops the stream and waits for the polling goroutine to exit.
+func (s *AppEventStream) Close() {
+	s.cancel()
+	<-s.done
+}
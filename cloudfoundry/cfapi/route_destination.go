@@ -0,0 +1,14 @@
+package cfapi
+
+// RouteDestination is the payload for POST /v3/routes/:guid/destinations,
+// the CF routing API primitive behind weighted/multi-protocol route
+// mappings. Weight and Protocol are optional: a nil Weight lets CF default
+// to even distribution across a route's destinations, and an empty
+// Protocol defaults to "http1".
+type RouteDestination struct {
+	RouteGUID string
+	AppGUID   string
+	Port      *int
+	Weight    *int
+	Protocol  string
+}
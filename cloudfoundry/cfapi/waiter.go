@@ -0,0 +1,236 @@
+package cfapi
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+// AppOperationWaiter polls a long-running app operation (start, stop, stage)
+// until it reaches one of Target, one of the non-retryable states, or Timeout
+// elapses. It is modeled on the operation-waiter pattern used by other
+// Terraform providers to drive resource.StateChangeConf with backoff instead
+// of a fixed sleep/poll loop.
+type AppOperationWaiter struct {
+	// RefreshFunc is called on every tick. It returns the current result,
+	// a stable state string, and any error encountered while refreshing.
+	RefreshFunc resource.StateRefreshFunc
+
+	Pending []string
+	Target  []string
+
+	Delay        time.Duration
+	MinTimeout   time.Duration
+	PollInterval time.Duration
+	Timeout      time.Duration
+
+	// snapshots records the last N states observed, so a failure can explain
+	// *why* the app never reached the target state instead of a bare
+	// "timeout while waiting for state to become" error.
+	snapshots []string
+}
+
+// maxSnapshots bounds how many status snapshots are retained for the error
+// message on failure.
+const maxSnapshots = 10
+
+// Conf builds the underlying resource.StateChangeConf driving this waiter.
+func (w *AppOperationWaiter) Conf() *resource.StateChangeConf {
+	return &resource.StateChangeConf{
+		Pending:      w.Pending,
+		Target:       w.Target,
+		Refresh:      w.recordingRefresh(),
+		Timeout:      w.Timeout,
+		Delay:        w.Delay,
+		MinTimeout:   w.MinTimeout,
+		PollInterval: w.PollInterval,
+	}
+}
+
+// Wait blocks until the operation reaches a target state, fails, or times
+// out, returning the final refreshed result.
+func (w *AppOperationWaiter) Wait() (interface{}, error) {
+	result, err := w.Conf().WaitForState()
+	if err != nil {
+		return result, fmt.Errorf("%s (last %d observed states: %v)", err, len(w.snapshots), w.snapshots)
+	}
+	return result, nil
+}
+
+func (w *AppOperationWaiter) recordingRefresh() resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		result, state, err := w.RefreshFunc()
+		if err == nil {
+			w.snapshots = append(w.snapshots, state)
+			if len(w.snapshots) > maxSnapshots {
+				w.snapshots = w.snapshots[len(w.snapshots)-maxSnapshots:]
+			}
+		}
+		return result, state, err
+	}
+}
+
+// AppInstancesRunningRefresh polls /v2/apps/{guid}/instances and reports
+// "running" once at least desired instances are in the RUNNING state, or
+// "crashed" if any instance reports CRASHED so callers can fail fast.
+func AppInstancesRunningRefresh(am *AppManager, appID string, desired int) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		instances, err := am.ReadAppInstances(appID)
+		if err != nil {
+			return nil, "", err
+		}
+		running := 0
+		for _, inst := range instances {
+			switch inst.State {
+			case "RUNNING":
+				running++
+			case "CRASHED":
+				return instances, "crashed", nil
+			}
+		}
+		if running >= desired {
+			return instances, "running", nil
+		}
+		return instances, "starting", nil
+	}
+}
+
+// AppInstancesHealthyRefresh polls /v2/apps/{guid}/instances the same way
+// AppInstancesRunningRefresh does, but instead of reporting "running" the
+// first time enough instances are up, it tracks consecutive passing and
+// failing observations and only settles once one side crosses its
+// http_health_check threshold -- "healthy" after healthyThreshold
+// consecutive polls with desired instances RUNNING, or "unhealthy" after
+// unhealthyThreshold consecutive polls that are not, mirroring the
+// readiness health check CF itself runs before routing traffic to an
+// instance.
+func AppInstancesHealthyRefresh(am *AppManager, appID string, desired, healthyThreshold, unhealthyThreshold int) resource.StateRefreshFunc {
+	consecutivePass, consecutiveFail := 0, 0
+	return func() (interface{}, string, error) {
+		instances, err := am.ReadAppInstances(appID)
+		if err != nil {
+			return nil, "", err
+		}
+
+		passing := true
+		for _, inst := range instances {
+			if inst.State != "RUNNING" {
+				passing = false
+			}
+		}
+		if passing && len(instances) >= desired {
+			consecutiveFail = 0
+			consecutivePass++
+		} else {
+			consecutivePass = 0
+			consecutiveFail++
+		}
+
+		if consecutivePass >= healthyThreshold {
+			return instances, "healthy", nil
+		}
+		if unhealthyThreshold > 0 && consecutiveFail >= unhealthyThreshold {
+			return instances, "unhealthy", nil
+		}
+		return instances, "checking", nil
+	}
+}
+
+// AppInstancesStoppedRefresh reports "stopped" once no instances remain.
+func AppInstancesStoppedRefresh(am *AppManager, appID string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		instances, err := am.ReadAppInstances(appID)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(instances) == 0 {
+			return instances, "stopped", nil
+		}
+		return instances, "draining", nil
+	}
+}
+
+// AppPackageStateRefresh reports the app's package_state ("PENDING",
+// "STAGED" or "FAILED"), reporting "PENDING" while it is not yet set.
+func AppPackageStateRefresh(am *AppManager, appID string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		app, err := am.ReadApp(appID)
+		if err != nil {
+			return nil, "", err
+		}
+		if app.PackageState == nil {
+			return app, "PENDING", nil
+		}
+		return app, *app.PackageState, nil
+	}
+}
+
+// AppDeploymentStateRefresh polls a /v3/deployments/{guid} resource and
+// reports its "status.value" (e.g. DEPLOYING, CANARY_ACTIVE once a canary
+// step's weight is live and healthy, DEPLOYED once fully rolled out, or
+// CANCELED).
+func AppDeploymentStateRefresh(am *AppManager, deploymentID string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		deployment, err := am.ReadDeployment(deploymentID)
+		if err != nil {
+			return nil, "", err
+		}
+		return deployment, deployment.Status, nil
+	}
+}
+
+// ServiceInstanceStateRefresh polls a service instance's last_operation
+// until it leaves "in progress", reporting the terminal "succeeded" or
+// "failed" state so callers can drive an AppOperationWaiter over broker
+// async provisioning/update/deprovisioning the same way they do over app
+// staging and start. An instance that no longer exists (a 404) reports
+// "succeeded", since that is how a completed deprovisioning looks.
+func ServiceInstanceStateRefresh(sm *ServiceManager, guid string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		instance, err := sm.ReadServiceInstance(guid)
+		if err != nil {
+			if strings.Contains(err.Error(), "status code: 404") {
+				return guid, "succeeded", nil
+			}
+			return nil, "", err
+		}
+		if instance.LastOperation == nil {
+			return instance, "succeeded", nil
+		}
+		return instance, instance.LastOperation.State, nil
+	}
+}
+
+// ServiceKeyStateRefresh polls a service key's last_operation the same way
+// ServiceInstanceStateRefresh does for service instances.
+func ServiceKeyStateRefresh(sm *ServiceManager, guid string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		key, err := sm.ReadServiceKey(guid)
+		if err != nil {
+			if strings.Contains(err.Error(), "status code: 404") {
+				return guid, "succeeded", nil
+			}
+			return nil, "", err
+		}
+		if key.LastOperation == nil {
+			return key, "succeeded", nil
+		}
+		return key, key.LastOperation.State, nil
+	}
+}
+
+// AppStateRefresh polls the app's lifecycle state (e.g. STARTED/STOPPED).
+func AppStateRefresh(am *AppManager, appID, target string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		app, err := am.ReadApp(appID)
+		if err != nil {
+			return nil, "", err
+		}
+		if app.State == nil {
+			return app, "", nil
+		}
+		return app, *app.State, nil
+	}
+}
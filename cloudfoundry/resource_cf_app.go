@@ -1,12 +1,17 @@
 package cloudfoundry
 
 import (
+	"context"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -21,6 +26,62 @@ import (
 // DefaultAppTimeout - Timeout (in seconds) when pushing apps to CF
 const DefaultAppTimeout = 60
 
+// DefaultAppPollInterval - minimum delay (in seconds) between waiter polls
+const DefaultAppPollInterval = 5
+
+// DefaultArtifactCacheDir - where hashed app source artifacts are cached
+// on disk when 'artifact_cache' is not configured.
+const DefaultArtifactCacheDir = ".terraform/cf-artifact-cache"
+
+// appTimeouts holds the per-phase timeouts used by the operation waiter.
+// When the 'timeouts' block is not set, every phase falls back to the
+// legacy flat 'timeout' attribute so existing configs keep working.
+type appTimeouts struct {
+	Staging      time.Duration
+	Start        time.Duration
+	Stop         time.Duration
+	Drain        time.Duration
+	PollInterval time.Duration
+}
+
+func getAppTimeouts(d *schema.ResourceData) appTimeouts {
+	legacy := time.Second * time.Duration(d.Get("timeout").(int))
+	t := appTimeouts{
+		Staging:      legacy,
+		Start:        legacy,
+		Stop:         legacy,
+		Drain:        legacy,
+		PollInterval: DefaultAppPollInterval * time.Second,
+	}
+	if v, ok := d.GetOk("timeouts"); ok {
+		tt := v.([]interface{})[0].(map[string]interface{})
+		t.Staging = time.Second * time.Duration(tt["staging"].(int))
+		t.Start = time.Second * time.Duration(tt["start"].(int))
+		t.Stop = time.Second * time.Duration(tt["stop"].(int))
+		t.Drain = time.Second * time.Duration(tt["drain"].(int))
+		t.PollInterval = time.Second * time.Duration(tt["poll_interval"].(int))
+	}
+	return t
+}
+
+// appHealthCheckPolling extracts the poll cadence and pass/fail thresholds
+// from the 'http_health_check' block, so create/update waits can settle on
+// the same schedule CF itself uses to decide an instance is routable
+// instead of the generic 'timeouts' block's fixed PollInterval. ok is
+// false when 'http_health_check' is not set, in which case callers should
+// fall back to the generic timeouts-driven wait.
+func appHealthCheckPolling(d *schema.ResourceData) (pollInterval time.Duration, healthyThreshold, unhealthyThreshold int, ok bool) {
+	v, ok := d.GetOk("http_health_check")
+	if !ok {
+		return 0, 0, 0, false
+	}
+	httpHealthCheck := v.([]interface{})[0].(map[string]interface{})
+	pollInterval = time.Second * time.Duration(httpHealthCheck["check_interval"].(int))
+	healthyThreshold = httpHealthCheck["healthy_threshold"].(int)
+	unhealthyThreshold = httpHealthCheck["unhealthy_threshold"].(int)
+	return pollInterval, healthyThreshold, unhealthyThreshold, true
+}
+
 func resourceApp() *schema.Resource {
 
 	return &schema.Resource{
@@ -89,9 +150,44 @@ func resourceApp() *schema.Resource {
 				Computed: true,
 			},
 			"timeout": &schema.Schema{
-				Type:     schema.TypeInt,
+				Type:       schema.TypeInt,
+				Optional:   true,
+				Default:    DefaultAppTimeout,
+				Deprecated: "Use the new 'timeouts' block for per-phase timeouts instead.",
+			},
+			"timeouts": &schema.Schema{
+				Type:     schema.TypeList,
 				Optional: true,
-				Default:  DefaultAppTimeout,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"staging": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+							Default:  DefaultAppTimeout,
+						},
+						"start": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+							Default:  DefaultAppTimeout,
+						},
+						"stop": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+							Default:  DefaultAppTimeout,
+						},
+						"drain": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+							Default:  DefaultAppTimeout,
+						},
+						"poll_interval": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+							Default:  5,
+						},
+					},
+				},
 			},
 			"stopped": &schema.Schema{
 				Type:     schema.TypeBool,
@@ -106,7 +202,8 @@ func resourceApp() *schema.Resource {
 			"docker_image": &schema.Schema{
 				Type:          schema.TypeString,
 				Optional:      true,
-				ConflictsWith: []string{"git", "github_release", "url"},
+				ConflictsWith: []string{"git", "github_release", "url", "docker"},
+				Deprecated:    "Use the new 'docker' block instead.",
 			},
 			"docker_credentials": &schema.Schema{
 				Type:          schema.TypeMap,
@@ -114,6 +211,42 @@ func resourceApp() *schema.Resource {
 				Sensitive:     true,
 				ConflictsWith: []string{"git", "github_release", "url"},
 			},
+			"docker": &schema.Schema{
+				Type:          schema.TypeList,
+				Optional:      true,
+				MaxItems:      1,
+				ConflictsWith: []string{"git", "github_release", "url", "docker_image"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"image": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"digest": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"pinned_digest": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"pull_policy": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "if_not_present",
+							ValidateFunc: validation.StringInSlice([]string{"always", "if_not_present", "never"}, false),
+						},
+						"registry_mirror": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"platform": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+					},
+				},
+			},
 			"git": &schema.Schema{
 				Type:          schema.TypeList,
 				Optional:      true,
@@ -148,6 +281,10 @@ func resourceApp() *schema.Resource {
 							Type:     schema.TypeString,
 							Optional: true,
 						},
+						"commit": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
 					},
 				},
 			},
@@ -269,6 +406,7 @@ func resourceApp() *schema.Resource {
 				Optional:      true,
 				MinItems:      1,
 				ConflictsWith: []string{"route"},
+				Deprecated:    "Use the standalone 'cloudfoundry_route_mapping' resource instead, which allows a route to be mapped to more than one app.",
 				Set:           hashRouteMappingSet,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
@@ -284,6 +422,16 @@ func resourceApp() *schema.Resource {
 							Deprecated:   "Not yet implemented!",
 							ValidateFunc: validation.IntBetween(1, 65535),
 						},
+						"weight": &schema.Schema{
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ValidateFunc: validation.IntBetween(1, 100),
+						},
+						"protocol": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringInSlice([]string{"http1", "http2", "tcp"}, false),
+						},
 						"mapping_id": &schema.Schema{
 							Type:     schema.TypeString,
 							Computed: true,
@@ -297,6 +445,10 @@ func resourceApp() *schema.Resource {
 				Computed:  true,
 				Sensitive: true,
 			},
+			"source_sha256": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 			"health_check_http_endpoint": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
@@ -313,11 +465,68 @@ func resourceApp() *schema.Resource {
 				Optional: true,
 				Computed: true,
 			},
+			"http_health_check": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"check_interval": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+							Default:  30,
+						},
+						"healthy_threshold": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+							Default:  2,
+						},
+						"unhealthy_threshold": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+							Default:  2,
+						},
+						"request_host_header": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"response_status_codes": &schema.Schema{
+							Type:     schema.TypeSet,
+							Optional: true,
+							Elem:     &schema.Schema{Type: schema.TypeInt},
+							Set:      resourceIntegerSet,
+						},
+						"response_body_regex": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+					},
+				},
+			},
 			"disable_blue_green_deployment": &schema.Schema{
 				Type:     schema.TypeBool,
 				Optional: true,
 				Removed:  "See new blue_green section instead to enable blue/green type updates.",
 			},
+			"artifact_cache": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"dir": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  DefaultArtifactCacheDir,
+						},
+						"max_size_mb": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+							Default:  0, // 0 == unlimited
+						},
+					},
+				},
+			},
 			"blue_green": &schema.Schema{
 				Type:     schema.TypeList,
 				Optional: true,
@@ -333,6 +542,120 @@ func resourceApp() *schema.Resource {
 							Type:     schema.TypeString,
 							Optional: true,
 						},
+						"strategy": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "instance_swap",
+							ValidateFunc: validation.StringInSlice([]string{"instance_swap", "canary"}, false),
+						},
+						"canary_steps": &schema.Schema{
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"weight": &schema.Schema{
+										Type:         schema.TypeInt,
+										Required:     true,
+										ValidateFunc: validation.IntBetween(1, 100),
+									},
+									"hold": &schema.Schema{
+										Type:     schema.TypeString,
+										Optional: true,
+										Default:  "0s",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"validation": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"url": &schema.Schema{
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.NoZeroValues,
+						},
+						"expected_status": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+							Default:  200,
+						},
+						"body_regex": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"retries": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+							Default:  3,
+						},
+						"interval": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "5s",
+						},
+						"initial_delay": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "0s",
+						},
+						"timeout": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "60s",
+						},
+					},
+				},
+			},
+			"rolling": &schema.Schema{
+				Type:          schema.TypeList,
+				Optional:      true,
+				MaxItems:      1,
+				ConflictsWith: []string{"blue_green"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"max_in_flight": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+							Default:  1,
+						},
+						"auto_rollback_on_health_check_failure": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  true,
+						},
+						"canary": &schema.Schema{
+							Type:     schema.TypeList,
+							Optional: true,
+							MaxItems: 1,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"steps": &schema.Schema{
+										Type:     schema.TypeList,
+										Required: true,
+										MinItems: 1,
+										Elem: &schema.Resource{
+											Schema: map[string]*schema.Schema{
+												"weight": &schema.Schema{
+													Type:         schema.TypeInt,
+													Required:     true,
+													ValidateFunc: validation.IntBetween(1, 100),
+												},
+												"pause": &schema.Schema{
+													Type:     schema.TypeString,
+													Optional: true,
+													Default:  "0s",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
 					},
 				},
 			},
@@ -348,15 +671,21 @@ func resourceApp() *schema.Resource {
 		// TODO: find a way to test that this is correctly forcing a new resource
 		//       when you try to change an app to/from a docker container
 		CustomizeDiff: func(diff *schema.ResourceDiff, v interface{}) error {
-			if (diff.HasChange("docker_image") || diff.HasChange("docker_credentials")) &&
+			if (diff.HasChange("docker_image") || diff.HasChange("docker_credentials") || diff.HasChange("docker")) &&
 				(diff.HasChange("git") || diff.HasChange("github_release") || diff.HasChange("url")) {
 
-				for _, v := range []string{"docker_image", "docker_credentials", "git", "github_release", "url"} {
+				for _, v := range []string{"docker_image", "docker_credentials", "docker", "git", "github_release", "url"} {
 					if diff.HasChange(v) {
 						diff.ForceNew(v)
 					}
 				}
 			}
+			if err := validateRouteWeights(diff); err != nil {
+				return err
+			}
+			if err := resolveDockerDigestDrift(diff); err != nil {
+				return err
+			}
 			return nil
 		},
 	}
@@ -370,6 +699,20 @@ func validateAppHealthCheckType(v interface{}, k string) (ws []string, errs []er
 	return ws, errs
 }
 
+func intPtr(v int) *int {
+	return &v
+}
+
+// isDockerApp reports whether the app is sourced from a docker image, via
+// either the deprecated 'docker_image' string or the 'docker' block.
+func isDockerApp(d *schema.ResourceData) bool {
+	if _, ok := d.GetOk("docker_image"); ok {
+		return true
+	}
+	_, ok := d.GetOk("docker")
+	return ok
+}
+
 func validateAppDeposedMapEmpty(v interface{}, k string) (ws []string, errs []error) {
 	if len(v.(map[string]interface{})) != 0 {
 		errs = append(errs, fmt.Errorf("%q must not be set by the user", k))
@@ -440,6 +783,30 @@ func resourceAppCreate(d *schema.ResourceData, meta interface{}) error {
 		vv := v.(int)
 		app.HealthCheckTimeout = &vv
 	}
+	if v, ok := d.GetOk("http_health_check"); ok {
+		httpHealthCheck := v.([]interface{})[0].(map[string]interface{})
+		readinessType := "http"
+		app.ReadinessHealthCheckType = &readinessType
+		if app.HealthCheckHTTPEndpoint != nil {
+			app.ReadinessHealthCheckHTTPEndpoint = app.HealthCheckHTTPEndpoint
+		}
+		app.ReadinessHealthCheckInterval = intPtr(httpHealthCheck["check_interval"].(int))
+		app.ReadinessHealthyThreshold = intPtr(httpHealthCheck["healthy_threshold"].(int))
+		app.ReadinessUnhealthyThreshold = intPtr(httpHealthCheck["unhealthy_threshold"].(int))
+		if host, ok := httpHealthCheck["request_host_header"].(string); ok && len(host) > 0 {
+			app.ReadinessHealthCheckHostHeader = &host
+		}
+		if regex, ok := httpHealthCheck["response_body_regex"].(string); ok && len(regex) > 0 {
+			app.ReadinessHealthCheckBodyRegex = &regex
+		}
+		if codes, ok := httpHealthCheck["response_status_codes"]; ok {
+			var statusCodes []int
+			for _, c := range codes.(*schema.Set).List() {
+				statusCodes = append(statusCodes, c.(int))
+			}
+			app.ReadinessHealthCheckStatusCodes = &statusCodes
+		}
+	}
 	if v, ok := d.GetOk("environment"); ok {
 		vv := v.(map[string]interface{})
 		app.Environment = &vv
@@ -455,6 +822,20 @@ func resourceAppCreate(d *schema.ResourceData, meta interface{}) error {
 		vv := v.(map[string]interface{})
 		app.DockerCredentials = &vv
 	}
+	if v, ok = d.GetOk("docker"); ok {
+		dockerConfig := v.([]interface{})[0].(map[string]interface{})
+		creds, _ := d.GetOk("docker_credentials")
+		image, digest, err := resolveDockerImageDigest(dockerConfig, creds)
+		if err != nil {
+			return err
+		}
+		app.DockerImage = &image
+		dockerConfig["digest"] = digest
+		d.Set("docker", []map[string]interface{}{dockerConfig})
+		// Activate Diego for Docker
+		onDiego := true
+		app.Diego = &onDiego
+	}
 
 	appConfig := cfAppConfig{
 		app: app,
@@ -486,7 +867,8 @@ func resourceAppCreateCfApp(d *schema.ResourceData, meta interface{}, appConfig
 	var (
 		v interface{}
 
-		appPath string
+		appPath       string
+		appPathCached bool
 
 		defaultRoute, stageRoute, liveRoute string
 
@@ -498,11 +880,33 @@ func resourceAppCreateCfApp(d *schema.ResourceData, meta interface{}, appConfig
 	)
 
 	// Skip if Docker repo is given
-	if _, ok := d.GetOk("docker_image"); !ok {
-		appPath, err = prepareApp(app, d, session.Log)
-		if err != nil {
-			return err
+	if !isDockerApp(d) {
+		var sourceHash string
+
+		cacheKey, cacheable := sourceCacheKey(d)
+		if cacheable {
+			if cachedPath, cachedHash, ok := lookupArtifactInCache(d, cacheKey); ok {
+				session.Log.DebugMessage("Reusing cached artifact %s for app %s, skipping download", cachedPath, app.Name)
+				appPath, sourceHash, appPathCached = cachedPath, cachedHash, true
+			}
+		}
+
+		if !appPathCached {
+			appPath, err = prepareApp(app, d, session.Log)
+			if err != nil {
+				return err
+			}
+			sourceHash, err = hashArtifact(appPath)
+			if err != nil {
+				return err
+			}
+			if cacheable {
+				if err := recordArtifactInCache(d, appPath, sourceHash, cacheKey); err != nil {
+					session.Log.DebugMessage("Unable to update artifact cache for app %s: %s", app.ID, err)
+				}
+			}
 		}
+		d.Set("source_sha256", sourceHash)
 	}
 
 	if v, hasRouteConfig := d.GetOk("route"); hasRouteConfig {
@@ -558,7 +962,7 @@ func resourceAppCreateCfApp(d *schema.ResourceData, meta interface{}, appConfig
 	}
 	upload := make(chan error)
 	// Skip if Docker repo is given
-	if _, ok := d.GetOk("docker_image"); !ok {
+	if !isDockerApp(d) {
 
 		// Upload application binary / source asynchronously
 		go func() {
@@ -568,8 +972,10 @@ func resourceAppCreateCfApp(d *schema.ResourceData, meta interface{}, appConfig
 				return
 			}
 
-			// Do not remove files from the local file system
-			if v, ok := d.GetOk("url"); ok {
+			// Do not remove files from the local file system, nor the
+			// shared artifact cache blob appPath may be pointing at.
+			if appPathCached {
+			} else if v, ok := d.GetOk("url"); ok {
 				url := v.(string)
 
 				if !strings.HasPrefix(url, "file://") {
@@ -621,25 +1027,29 @@ func resourceAppCreateCfApp(d *schema.ResourceData, meta interface{}, appConfig
 	}
 
 	// Skip if Docker repo is given
-	if _, ok := d.GetOk("docker_image"); !ok {
+	if !isDockerApp(d) {
 		if err = <-upload; err != nil {
 			return err
 		}
 	}
 
-	timeout := time.Second * time.Duration(d.Get("timeout").(int))
+	timeouts := getAppTimeouts(d)
 	stopped := d.Get("stopped").(bool)
 
-	if _, ok := d.GetOk("docker_image"); ok {
+	if isDockerApp(d) {
 		if !stopped {
-			if err = am.StartDockerApp(app.ID, timeout); err != nil {
+			if err = withAppEvents(am, session.Log, app.ID, func() error {
+				return am.StartDockerApp(app.ID, timeouts.Start)
+			}); err != nil {
 				return err
 			}
 		}
 	} else if !stopped {
 		// Start application if not stopped
 		// state once upload has completed
-		if err = am.StartApp(app.ID, timeout); err != nil {
+		if err = withAppEvents(am, session.Log, app.ID, func() error {
+			return am.StartApp(app.ID, timeouts.Start)
+		}); err != nil {
 			return err
 		}
 	}
@@ -789,6 +1199,26 @@ func resourceAppUpdate(d *schema.ResourceData, meta interface{}) (err error) {
 	app.HealthCheckHTTPEndpoint = getChangedValueString("health_check_http_endpoint", &restart, d)
 	app.HealthCheckType = getChangedValueString("health_check_type", &restart, d)
 	app.HealthCheckTimeout = getChangedValueInt("health_check_timeout", &restart, d)
+	if d.HasChange("http_health_check") {
+		restart = true
+		if v, ok := d.GetOk("http_health_check"); ok {
+			httpHealthCheck := v.([]interface{})[0].(map[string]interface{})
+			readinessType := "http"
+			app.ReadinessHealthCheckType = &readinessType
+			app.ReadinessHealthCheckInterval = intPtr(httpHealthCheck["check_interval"].(int))
+			app.ReadinessHealthyThreshold = intPtr(httpHealthCheck["healthy_threshold"].(int))
+			app.ReadinessUnhealthyThreshold = intPtr(httpHealthCheck["unhealthy_threshold"].(int))
+			if host, ok := httpHealthCheck["request_host_header"].(string); ok && len(host) > 0 {
+				app.ReadinessHealthCheckHostHeader = &host
+			}
+			if regex, ok := httpHealthCheck["response_body_regex"].(string); ok && len(regex) > 0 {
+				app.ReadinessHealthCheckBodyRegex = &regex
+			}
+		} else {
+			noneType := "none"
+			app.ReadinessHealthCheckType = &noneType
+		}
+	}
 
 	restage := false // for changes where a full restage is required
 	app.Buildpack = getChangedValueString("buildpack", &restage, d)
@@ -804,6 +1234,20 @@ func resourceAppUpdate(d *schema.ResourceData, meta interface{}) (err error) {
 	//       service bindings are updates?)
 	app.DockerImage = getChangedValueString("docker_image", &update, d)
 	app.DockerCredentials = getChangedValueMap("docker_credentials", &update, d)
+	if d.HasChange("docker") {
+		if v, ok := d.GetOk("docker"); ok {
+			dockerConfig := v.([]interface{})[0].(map[string]interface{})
+			creds, _ := d.GetOk("docker_credentials")
+			image, digest, err := resolveDockerImageDigest(dockerConfig, creds)
+			if err != nil {
+				return err
+			}
+			app.DockerImage = &image
+			dockerConfig["digest"] = digest
+			d.Set("docker", []map[string]interface{}{dockerConfig})
+			update = true
+		}
+	}
 
 	if update || restart || restage {
 		// push any updates to CF, we'll do any restage/restart later
@@ -838,8 +1282,23 @@ func resourceAppUpdate(d *schema.ResourceData, meta interface{}) (err error) {
 		}
 	}
 
+	rolling := false
+	if _, ok := d.GetOk("rolling"); ok {
+		if restart || restage || d.HasChange("service_binding") ||
+			d.HasChange("url") || d.HasChange("git") || d.HasChange("github_release") || d.HasChange("add_content") {
+			rolling = true
+		}
+	}
+
 	if blueGreen {
-		err = resourceAppBlueGreenUpdate(d, meta, app)
+		blueGreenConfig := d.Get("blue_green").([]interface{})[0].(map[string]interface{})
+		if blueGreenConfig["strategy"].(string) == "canary" {
+			err = resourceAppBlueGreenCanaryUpdate(d, meta, app)
+		} else {
+			err = resourceAppBlueGreenUpdate(d, meta, app)
+		}
+	} else if rolling {
+		err = resourceAppRollingUpdate(d, meta, app)
 	} else {
 		// fall back to a standard update to the existing app
 		err = resourceAppStandardUpdate(d, meta, app, update, restart, restage)
@@ -869,6 +1328,8 @@ func resourceAppBlueGreenUpdate(d *schema.ResourceData, meta interface{}, newApp
 		venerableApp = v
 	}
 
+	originalName := venerableApp.Name
+
 	// Update origin app name
 	if venerableAppRefeshed, err := am.UpdateApp(cfapi.CCApp{ID: d.Id(), Name: venerableApp.Name + "-venerable"}); err != nil {
 		return err
@@ -876,16 +1337,39 @@ func resourceAppBlueGreenUpdate(d *schema.ResourceData, meta interface{}, newApp
 		venerableApp = venerableAppRefeshed
 	}
 
+	// renameVenerableBack restores the original app's name, used any time we
+	// abort a blue-green update before the new app has fully taken over.
+	renameVenerableBack := func() error {
+		_, err := am.UpdateApp(cfapi.CCApp{ID: venerableApp.ID, Name: originalName})
+		return err
+	}
+
 	appConfig := cfAppConfig{
 		app: newApp,
 	}
 	appConfig.app.Instances = func(i int) *int { return &i }(1) // start the staged app with only one instance (we'll scale it up later)
 	if err := resourceAppCreateCfApp(d, meta, &appConfig); err != nil {
+		renameVenerableBack()
 		return err
 	}
 	appConfig.app.Instances = newApp.Instances // restore final expected instances count
 
-	// TODO: Execute blue-green validation
+	// Execute blue-green validation against the newly staged app's stage
+	// route before any live route mapping is moved over to it. A failure
+	// here is still fully recoverable: the venerable app was never touched
+	// beyond its name, so we just delete the new app and rename venerable
+	// back.
+	if probes, ok := d.GetOk("validation"); ok {
+		if stageRoute, ok := appConfig.routeConfig["stage_route"].(string); ok && len(stageRoute) > 0 {
+			if err := runValidationProbes(session.Log, getListOfStructs(probes)); err != nil {
+				am.DeleteApp(appConfig.app.ID, true)
+				renameVenerableBack()
+				return fmt.Errorf("blue-green validation failed against stage_route %s, rolled back: %s", stageRoute, err)
+			}
+		} else {
+			session.Log.DebugMessage("No stage_route configured, skipping blue-green validation")
+		}
+	}
 
 	// now that we've passed validation, we've passed the point of no return
 	d.SetId(appConfig.app.ID)
@@ -924,11 +1408,7 @@ func resourceAppBlueGreenUpdate(d *schema.ResourceData, meta interface{}, newApp
 	}
 	d.SetPartial("route")
 
-	var timeoutDuration time.Duration
-	if v, ok := d.GetOk("timeout"); ok {
-		vv := v.(int)
-		timeoutDuration = time.Second * time.Duration(vv)
-	}
+	timeouts := getAppTimeouts(d)
 
 	// now scale up the new app and scale down the old app
 	venerableAppScale := cfapi.CCApp{
@@ -941,35 +1421,46 @@ func resourceAppBlueGreenUpdate(d *schema.ResourceData, meta interface{}, newApp
 		Name:      appConfig.app.Name,
 		Instances: func(i int) *int { return &i }(1),
 	}
-	session.Log.DebugMessage("newApp.Instances: %d", *newApp.Instances)
-	session.Log.DebugMessage("venerableApp.Instances: %d", *venerableAppScale.Instances)
-	for *newAppScale.Instances < *newApp.Instances || *venerableAppScale.Instances > 1 {
-		if *newAppScale.Instances < *newApp.Instances {
-			// scale up new
-			*newAppScale.Instances++
-			session.Log.DebugMessage("Scaling up new app %s to instance count %d", newAppScale.ID, *newAppScale.Instances)
-			if _, err := am.UpdateApp(newAppScale); err != nil {
-				return err
-			}
-			if *(appConfig.app.State) != "STOPPED" {
-				time.Sleep(time.Second * time.Duration(15))
-				// TODO: fix this wait
-				am.WaitForAppToStart(newAppScale, timeoutDuration)
-			}
+	// rollbackScaleUp reverts a mid-scale-up failure: scale the new app back
+	// to zero, scale venerable back to its original instance count, unmap
+	// the routes we just bound to the new app, and only delete the new app
+	// once venerable is confirmed healthy again.
+	// venerableAppScale.Instances shares its pointer with venerableApp, and
+	// the scale loop decrements it, so keep the original count by value.
+	venerableOriginalInstances := *venerableApp.Instances
+	rollbackScaleUp := func(cause error) error {
+		session.Log.DebugMessage("Rolling back blue-green update for app %s: %s", venerableApp.ID, cause)
+
+		zero := 0
+		am.UpdateApp(cfapi.CCApp{ID: newAppScale.ID, Instances: &zero})
+
+		if _, err := am.UpdateApp(cfapi.CCApp{ID: venerableApp.ID, Instances: &venerableOriginalInstances}); err != nil {
+			return fmt.Errorf("%s (additionally failed to restore venerable app instance count: %s)", cause, err)
+		}
+		restoredVenerable := venerableApp
+		restoredVenerable.Instances = &venerableOriginalInstances
+		if err := am.WaitForAppToStart(restoredVenerable, timeouts.Start); err != nil {
+			return fmt.Errorf("%s (additionally, venerable app did not come back up after rollback: %s)", cause, err)
 		}
 
-		if *venerableAppScale.Instances > 1 {
-			// scale down old
-			*venerableAppScale.Instances--
-			session.Log.DebugMessage("Scaling down venerable app %s to instance count %d", venerableAppScale.ID, *venerableAppScale.Instances)
-			if _, err := am.UpdateApp(venerableAppScale); err != nil {
-				return err
-			}
-			if *venerableApp.State != "STOPPED" {
-				time.Sleep(time.Second * time.Duration(5))
-				// TODO: wait for instance to stop
+		for _, mappingKey := range []string{"default_route_mapping_id", "live_route_mapping_id"} {
+			if mappingID, ok := appConfig.routeConfig[mappingKey].(string); ok && len(mappingID) > 0 {
+				rm.DeleteRouteMapping(mappingID)
 			}
 		}
+
+		am.DeleteApp(newAppScale.ID, true)
+		renameVenerableBack()
+
+		return cause
+	}
+
+	session.Log.DebugMessage("newApp.Instances: %d", *newApp.Instances)
+	session.Log.DebugMessage("venerableApp.Instances: %d", *venerableAppScale.Instances)
+	if err := withAppEvents(am, session.Log, newAppScale.ID, func() error {
+		return resourceAppBlueGreenScaleLoop(d, am, session.Log, appConfig, &newAppScale, &venerableApp, &venerableAppScale, timeouts, rollbackScaleUp)
+	}); err != nil {
+		return err
 	}
 
 	// now delete the old application
@@ -986,25 +1477,373 @@ func resourceAppBlueGreenUpdate(d *schema.ResourceData, meta interface{}, newApp
 	return nil
 }
 
-func resourceAppStandardUpdate(d *schema.ResourceData, meta interface{}, app cfapi.CCApp, update bool, restart bool, restage bool) error {
-	session := meta.(*cfapi.Session)
-	if session == nil {
-		return fmt.Errorf("client is nil")
-	}
-
-	am := session.AppManager()
-	rm := session.RouteManager()
+// resourceAppBlueGreenScaleLoop performs the instance-swap portion of
+// resourceAppBlueGreenUpdate: it alternately scales the new app up and the
+// venerable app down by one instance at a time, waiting for each side to
+// settle before continuing, until the new app is at full strength and the
+// venerable app is down to a single instance.
+func resourceAppBlueGreenScaleLoop(d *schema.ResourceData, am *cfapi.AppManager, log *cfapi.Logger, appConfig cfAppConfig, newAppScale *cfapi.CCApp, venerableApp *cfapi.CCApp, venerableAppScale *cfapi.CCApp, timeouts appTimeouts, rollbackScaleUp func(error) error) error {
 
-	app.ID = d.Id()
+	healthPollInterval, healthyThreshold, unhealthyThreshold, hasHealthCheck := appHealthCheckPolling(d)
 
-	if update || restart || restage {
-		// push any updates to CF, we'll do any restage/restart later
-		var err error
-		if app, err = am.UpdateApp(app); err != nil {
-			return err
+	for *newAppScale.Instances < *appConfig.app.Instances || *venerableAppScale.Instances > 1 {
+		if *newAppScale.Instances < *appConfig.app.Instances {
+			// scale up new
+			*newAppScale.Instances++
+			log.DebugMessage("Scaling up new app %s to instance count %d", newAppScale.ID, *newAppScale.Instances)
+			if _, err := am.UpdateApp(*newAppScale); err != nil {
+				return rollbackScaleUp(err)
+			}
+			if *(appConfig.app.State) != "STOPPED" {
+				var waiter cfapi.AppOperationWaiter
+				if hasHealthCheck {
+					// Poll on the app's own http_health_check cadence and
+					// settle only once healthy_threshold consecutive polls
+					// pass (or bail after unhealthy_threshold consecutive
+					// failures), instead of the generic one-shot "enough
+					// instances are RUNNING" check.
+					waiter = cfapi.AppOperationWaiter{
+						RefreshFunc:  cfapi.AppInstancesHealthyRefresh(am, newAppScale.ID, *newAppScale.Instances, healthyThreshold, unhealthyThreshold),
+						Pending:      []string{"checking"},
+						Target:       []string{"healthy"},
+						Timeout:      timeouts.Start,
+						Delay:        healthPollInterval,
+						MinTimeout:   healthPollInterval,
+						PollInterval: healthPollInterval,
+					}
+				} else {
+					waiter = cfapi.AppOperationWaiter{
+						RefreshFunc:  cfapi.AppInstancesRunningRefresh(am, newAppScale.ID, *newAppScale.Instances),
+						Pending:      []string{"starting"},
+						Target:       []string{"running"},
+						Timeout:      timeouts.Start,
+						Delay:        timeouts.PollInterval,
+						MinTimeout:   timeouts.PollInterval,
+						PollInterval: timeouts.PollInterval,
+					}
+				}
+				if _, err := waiter.Wait(); err != nil {
+					return rollbackScaleUp(err)
+				}
+			}
 		}
-		setAppArguments(app, d)
-	}
+
+		if *venerableAppScale.Instances > 1 {
+			// scale down old
+			*venerableAppScale.Instances--
+			log.DebugMessage("Scaling down venerable app %s to instance count %d", venerableAppScale.ID, *venerableAppScale.Instances)
+			if _, err := am.UpdateApp(*venerableAppScale); err != nil {
+				return err
+			}
+			if *venerableApp.State != "STOPPED" {
+				waiter := cfapi.AppOperationWaiter{
+					// settled once no more than the new, lower instance count remains
+					RefreshFunc: func() (interface{}, string, error) {
+						instances, err := am.ReadAppInstances(venerableAppScale.ID)
+						if err != nil {
+							return nil, "", err
+						}
+						if len(instances) <= *venerableAppScale.Instances {
+							return instances, "stopped", nil
+						}
+						return instances, "draining", nil
+					},
+					Pending:      []string{"draining"},
+					Target:       []string{"stopped"},
+					Timeout:      timeouts.Drain,
+					Delay:        timeouts.PollInterval,
+					MinTimeout:   timeouts.PollInterval,
+					PollInterval: timeouts.PollInterval,
+				}
+				if _, err := waiter.Wait(); err != nil {
+					log.DebugMessage("Venerable app %s did not fully drain before continuing: %s", venerableAppScale.ID, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// canaryStep is one entry of a rolling deployment's canary plan.
+type canaryStep struct {
+	Weight int
+	Pause  time.Duration
+}
+
+func getCanarySteps(rollingConfig map[string]interface{}) ([]canaryStep, error) {
+	var steps []canaryStep
+	canaryBlocks := rollingConfig["canary"].([]interface{})
+	if len(canaryBlocks) == 0 {
+		return steps, nil
+	}
+	canaryConfig := canaryBlocks[0].(map[string]interface{})
+	for _, s := range canaryConfig["steps"].([]interface{}) {
+		step := s.(map[string]interface{})
+		pause, err := time.ParseDuration(step["pause"].(string))
+		if err != nil {
+			return nil, fmt.Errorf("invalid rolling.canary.steps.pause %q: %s", step["pause"].(string), err)
+		}
+		steps = append(steps, canaryStep{Weight: step["weight"].(int), Pause: pause})
+	}
+	return steps, nil
+}
+
+// resourceAppRollingUpdate drives a CF v3 rolling deployment in place of the
+// old app's process, optionally progressing through weighted canary steps
+// before cutting all traffic over. Unlike blue/green this never doubles the
+// app's instance footprint: the same app GUID keeps receiving traffic
+// throughout, with CF itself managing the in-flight old/new instance mix.
+func resourceAppRollingUpdate(d *schema.ResourceData, meta interface{}, newApp cfapi.CCApp) error {
+
+	session := meta.(*cfapi.Session)
+	if session == nil {
+		return fmt.Errorf("client is nil")
+	}
+
+	am := session.AppManager()
+	rollingConfig := d.Get("rolling").([]interface{})[0].(map[string]interface{})
+	maxInFlight := rollingConfig["max_in_flight"].(int)
+	autoRollback := rollingConfig["auto_rollback_on_health_check_failure"].(bool)
+
+	steps, err := getCanarySteps(rollingConfig)
+	if err != nil {
+		return err
+	}
+
+	timeouts := getAppTimeouts(d)
+	// CF's own deployment controller polls each canary step's readiness
+	// health check on http_health_check's check_interval before marking it
+	// CANARY_ACTIVE, so match our poll cadence to it when configured
+	// instead of the generic timeouts.PollInterval.
+	if healthPollInterval, _, _, ok := appHealthCheckPolling(d); ok {
+		timeouts.PollInterval = healthPollInterval
+	}
+	newApp.ID = d.Id()
+
+	deployment, err := am.CreateDeployment(newApp.ID, cfapi.CCDeploymentRequest{
+		Strategy:    "rolling",
+		MaxInFlight: &maxInFlight,
+	})
+	if err != nil {
+		return err
+	}
+
+	rollback := func(cause error) error {
+		session.Log.DebugMessage("Rolling deployment %s failed, cancelling: %s", deployment.ID, cause)
+		if !autoRollback {
+			return cause
+		}
+		if err := am.CancelDeployment(deployment.ID); err != nil {
+			return fmt.Errorf("%s (additionally failed to cancel deployment %s: %s)", cause, deployment.ID, err)
+		}
+		return cause
+	}
+
+	for i, step := range steps {
+		session.Log.DebugMessage("Advancing rolling deployment %s to canary step %d (weight=%d)", deployment.ID, i, step.Weight)
+		if err := am.SetDeploymentCanaryWeight(deployment.ID, step.Weight); err != nil {
+			return rollback(err)
+		}
+		// A deployment paused at an intermediate canary weight has reached
+		// "CANARY_ACTIVE" (the requested weight is live and healthy), not
+		// "DEPLOYED" (the terminal, fully-cut-over state) -- only finalWaiter,
+		// after the loop, requires the latter.
+		waiter := cfapi.AppOperationWaiter{
+			RefreshFunc:  cfapi.AppDeploymentStateRefresh(am, deployment.ID),
+			Pending:      []string{"DEPLOYING"},
+			Target:       []string{"CANARY_ACTIVE", "DEPLOYED"},
+			Timeout:      timeouts.Start,
+			PollInterval: timeouts.PollInterval,
+		}
+		if _, err := waiter.Wait(); err != nil {
+			return rollback(err)
+		}
+		if step.Pause > 0 {
+			time.Sleep(step.Pause)
+		}
+	}
+
+	finalWaiter := cfapi.AppOperationWaiter{
+		RefreshFunc:  cfapi.AppDeploymentStateRefresh(am, deployment.ID),
+		Pending:      []string{"DEPLOYING"},
+		Target:       []string{"DEPLOYED"},
+		Timeout:      timeouts.Start,
+		PollInterval: timeouts.PollInterval,
+	}
+	if _, err := finalWaiter.Wait(); err != nil {
+		return rollback(err)
+	}
+
+	app, err := am.ReadApp(newApp.ID)
+	if err != nil {
+		return err
+	}
+	setAppArguments(app, d)
+
+	return nil
+}
+
+// resourceAppBlueGreenCanaryUpdate is the 'strategy = "canary"' counterpart
+// to resourceAppBlueGreenUpdate: instead of swapping instance counts between
+// the venerable and new app, it stages the new app at its full instance
+// count immediately and shifts a fraction of live traffic to it at each
+// canary_steps weight, validating and holding at every step. Because both
+// apps run at full capacity throughout, this matches real load distribution
+// for apps where per-instance scaling does not.
+func resourceAppBlueGreenCanaryUpdate(d *schema.ResourceData, meta interface{}, newApp cfapi.CCApp) error {
+
+	session := meta.(*cfapi.Session)
+	if session == nil {
+		return fmt.Errorf("client is nil")
+	}
+
+	am := session.AppManager()
+	rm := session.RouteManager()
+
+	venerableApp, err := am.ReadApp(d.Id())
+	if err != nil {
+		return err
+	}
+	originalName := venerableApp.Name
+
+	if venerableAppRefreshed, err := am.UpdateApp(cfapi.CCApp{ID: d.Id(), Name: venerableApp.Name + "-venerable"}); err != nil {
+		return err
+	} else {
+		venerableApp = venerableAppRefreshed
+	}
+
+	renameVenerableBack := func() error {
+		_, err := am.UpdateApp(cfapi.CCApp{ID: venerableApp.ID, Name: originalName})
+		return err
+	}
+
+	appConfig := cfAppConfig{app: newApp}
+	if err := resourceAppCreateCfApp(d, meta, &appConfig); err != nil {
+		renameVenerableBack()
+		return err
+	}
+
+	probes := getListOfStructs(d.Get("validation"))
+
+	if stageRoute, ok := appConfig.routeConfig["stage_route"].(string); ok && len(stageRoute) > 0 && len(probes) > 0 {
+		if err := runValidationProbes(session.Log, probes); err != nil {
+			am.DeleteApp(appConfig.app.ID, true)
+			renameVenerableBack()
+			return fmt.Errorf("blue-green validation failed against stage_route %s, rolled back: %s", stageRoute, err)
+		}
+	}
+
+	d.SetId(appConfig.app.ID)
+	setAppArguments(appConfig.app, d)
+
+	deposedResources := d.Get("deposed").(map[string]interface{})
+	deposedResources[venerableApp.ID] = "application"
+	d.Set("deposed", deposedResources)
+
+	// Bind the new app to the same routes as venerable at weight 0, so it is
+	// wired up but not yet receiving any traffic.
+	weightMappings := map[string]string{} // route id -> mapping id on the new app
+	for _, routeKey := range []string{"default_route", "live_route"} {
+		routeID, err := validateRoute(appConfig.routeConfig, routeKey, venerableApp.ID, rm)
+		if err != nil {
+			am.DeleteApp(appConfig.app.ID, true)
+			renameVenerableBack()
+			return err
+		}
+		if len(routeID) == 0 {
+			continue
+		}
+		mappingID, err := rm.CreateRouteDestination(cfapi.RouteDestination{
+			RouteGUID: routeID,
+			AppGUID:   appConfig.app.ID,
+			Weight:    intPtr(0),
+		})
+		if err != nil {
+			am.DeleteApp(appConfig.app.ID, true)
+			renameVenerableBack()
+			return err
+		}
+		appConfig.routeConfig[routeKey+"_mapping_id"] = mappingID
+		weightMappings[routeID] = mappingID
+	}
+	d.SetPartial("route")
+
+	blueGreenConfig := d.Get("blue_green").([]interface{})[0].(map[string]interface{})
+
+	rollback := func(cause error) error {
+		session.Log.DebugMessage("Canary blue-green update for app %s failed, rolling back: %s", venerableApp.ID, cause)
+		for _, mappingID := range weightMappings {
+			if err := rm.UpdateRouteMappingWeight(mappingID, 0); err != nil {
+				session.Log.DebugMessage("Unable to reset weight for mapping %s: %s", mappingID, err)
+			}
+		}
+		am.DeleteApp(appConfig.app.ID, true)
+		renameVenerableBack()
+		return cause
+	}
+
+	for i, s := range getListOfStructs(blueGreenConfig["canary_steps"]) {
+		weight := s["weight"].(int)
+		hold, err := time.ParseDuration(s["hold"].(string))
+		if err != nil {
+			return rollback(fmt.Errorf("invalid blue_green.canary_steps.hold: %s", err))
+		}
+
+		session.Log.DebugMessage("Advancing canary blue-green update for app %s to step %d (weight=%d)", appConfig.app.ID, i, weight)
+		for _, mappingID := range weightMappings {
+			if err := rm.UpdateRouteMappingWeight(mappingID, weight); err != nil {
+				return rollback(err)
+			}
+		}
+
+		if len(probes) > 0 {
+			if err := runValidationProbes(session.Log, probes); err != nil {
+				return rollback(err)
+			}
+		}
+
+		if hold > 0 {
+			time.Sleep(hold)
+		}
+	}
+
+	// Cut over fully and tear down the venerable app.
+	for _, mappingID := range weightMappings {
+		if err := rm.UpdateRouteMappingWeight(mappingID, 100); err != nil {
+			return rollback(err)
+		}
+	}
+
+	if err := am.DeleteApp(venerableApp.ID, true); err != nil {
+		return err
+	}
+	deposedResources = d.Get("deposed").(map[string]interface{})
+	delete(deposedResources, venerableApp.ID)
+	d.Set("deposed", deposedResources)
+
+	return nil
+}
+
+func resourceAppStandardUpdate(d *schema.ResourceData, meta interface{}, app cfapi.CCApp, update bool, restart bool, restage bool) error {
+	session := meta.(*cfapi.Session)
+	if session == nil {
+		return fmt.Errorf("client is nil")
+	}
+
+	am := session.AppManager()
+	rm := session.RouteManager()
+
+	app.ID = d.Id()
+
+	if update || restart || restage {
+		// push any updates to CF, we'll do any restage/restart later
+		var err error
+		if app, err = am.UpdateApp(app); err != nil {
+			return err
+		}
+		setAppArguments(app, d)
+	}
 
 	if d.HasChange("service_binding") {
 
@@ -1163,14 +2002,14 @@ func resourceAppStandardUpdate(d *schema.ResourceData, meta interface{}, app cfa
 			if err := validateRoute(app.ID, routeID, rm); err != nil {
 				return err
 			}
-			if mappingID, err := rm.CreateRouteMapping(routeID, app.ID, nil); err != nil {
+			mappingID, err := createRouteMappingFromData(rm, routeID, app.ID, data)
+			if err != nil {
+				return err
+			}
+			data["mapping_id"] = mappingID
+			updatedRoutes.Add(data)
+			if err := d.Set("routes", updatedRoutes); err != nil {
 				return err
-			} else {
-				data["mapping_id"] = mappingID
-				updatedRoutes.Add(data)
-				if err := d.Set("routes", updatedRoutes); err != nil {
-					return err
-				}
 			}
 			// read mapping port
 			if mapping, err := rm.ReadRouteMapping(data["mapping_id"].(string)); err != nil {
@@ -1202,17 +2041,41 @@ func resourceAppStandardUpdate(d *schema.ResourceData, meta interface{}, app cfa
 			}
 		}
 
-		// mappings which may need updating
-		// TODO: need to implement this in order to handle the port and exclusive fields
-		/* oldDataList := os.Intersection(ns).List()
+		// mappings which may need updating: since hashRouteMappingSet only
+		// hashes the "route" field, a weight/protocol-only change shows up
+		// in the intersection rather than the add/remove diffs above. Patch
+		// those destinations in place instead of deleting and recreating
+		// the mapping, so traffic is never dropped mid-update.
+		oldDataList := os.Intersection(ns).List()
 		for i, r := range ns.Intersection(os).List() {
 			oldData := oldDataList[i].(map[string]interface{})
 			newData := r.(map[string]interface{})
 
-			if !reflect.DeepEqual(oldData, newData) {
+			oldWeight, _ := oldData["weight"].(int)
+			newWeight, _ := newData["weight"].(int)
+			oldProtocol, _ := oldData["protocol"].(string)
+			newProtocol, _ := newData["protocol"].(string)
 
+			if oldWeight != newWeight || oldProtocol != newProtocol {
+				mappingID, ok := oldData["mapping_id"].(string)
+				if !ok || len(mappingID) == 0 {
+					continue
+				}
+				var weightPtr *int
+				if newWeight > 0 {
+					weightPtr = &newWeight
+				}
+				if err := rm.UpdateRouteDestination(mappingID, weightPtr, newProtocol); err != nil {
+					return err
+				}
+				newData["mapping_id"] = mappingID
+				updatedRoutes.Remove(oldData)
+				updatedRoutes.Add(newData)
+				if err := d.Set("routes", updatedRoutes); err != nil {
+					return err
+				}
 			}
-		} */
+		}
 
 		d.SetPartial("routes") // routes updates complete, save them to state
 	}
@@ -1229,26 +2092,60 @@ func resourceAppStandardUpdate(d *schema.ResourceData, meta interface{}, app cfa
 			addContent []map[string]interface{}
 		)
 
-		if appPathCalc, err := prepareApp(app, d, session.Log); err != nil {
-			return err
+		var sourceHash string
+
+		cacheKey, cacheable := sourceCacheKey(d)
+		cachedPath, cachedHash, cacheHit := "", "", false
+		if cacheable {
+			cachedPath, cachedHash, cacheHit = lookupArtifactInCache(d, cacheKey)
+		}
+
+		if cacheHit {
+			session.Log.DebugMessage("Reusing cached artifact %s for app %s, skipping download", cachedPath, app.Name)
+			appPath = cachedPath
+			sourceHash = cachedHash
 		} else {
+			appPathCalc, err := prepareApp(app, d, session.Log)
+			if err != nil {
+				return err
+			}
 			appPath = appPathCalc
+			defer func() {
+				os.RemoveAll(appPath)
+			}()
+
+			sourceHash, err = hashArtifact(appPath)
+			if err != nil {
+				return err
+			}
+			if cacheable {
+				if err := recordArtifactInCache(d, appPath, sourceHash, cacheKey); err != nil {
+					session.Log.DebugMessage("Unable to update artifact cache for app %s: %s", app.ID, err)
+				}
+			}
 		}
-		defer func() {
-			os.RemoveAll(appPath)
-		}()
+		d.Set("source_sha256", sourceHash)
+
 		if v, ok = d.GetOk("add_content"); ok {
 			addContent = getListOfStructs(v)
 		}
 
-		if err := am.UploadApp(app, appPath, addContent); err != nil {
-			return err
+		existingPackage, err := am.ReadAppPackage(app.ID)
+		if err == nil && existingPackage.Hash != nil && *existingPackage.Hash == sourceHash {
+			// The bits at appPath hash identically to the package CC already
+			// has for this app (the git ref/tag/commit or url target has not
+			// actually moved) -- skip the redundant upload entirely.
+			session.Log.DebugMessage("Skipping upload for app %s: source_sha256 %s already present on CC", app.ID, sourceHash)
+		} else {
+			if err := am.UploadApp(app, appPath, addContent); err != nil {
+				return err
+			}
+			binaryUpdated = true
 		}
-		binaryUpdated = true
 	}
 
 	// now that all of the reconfiguration is done, we can deal doing a restage or restart, as required
-	timeout := time.Second * time.Duration(d.Get("timeout").(int))
+	timeouts := getAppTimeouts(d)
 
 	// check the package state of the application after binary upload
 	var curApp cfapi.CCApp
@@ -1261,7 +2158,19 @@ func resourceAppStandardUpdate(d *schema.ResourceData, meta interface{}, app cfa
 		// for right now, I have observed this after a service binding update as well, but I have no idea what other
 		// optierations might cause this.  For now, we'll just do a blanket check since calling restage when the app
 		// is in this state causes the API to throw an error.
-		time.Sleep(time.Second * time.Duration(5)) // pause for a few seconds here to ensure the CF API has caught up
+		waiter := cfapi.AppOperationWaiter{
+			RefreshFunc:  cfapi.AppPackageStateRefresh(am, app.ID),
+			Pending:      []string{},
+			Target:       []string{"STAGED", "FAILED", "PENDING"},
+			Timeout:      15 * time.Second,
+			Delay:        5 * time.Second, // give the CF API a moment to catch up before the first read
+			MinTimeout:   2 * time.Second,
+		}
+		if v, err := waiter.Wait(); err != nil {
+			return err
+		} else {
+			curApp = v.(cfapi.CCApp)
+		}
 		if *curApp.PackageState != "PENDING" {
 			// if it's not already pending, we need to restage
 			restage = true
@@ -1275,20 +2184,25 @@ func resourceAppStandardUpdate(d *schema.ResourceData, meta interface{}, app cfa
 	}
 
 	if restage {
-		if err := am.RestageApp(app.ID, timeout); err != nil {
-			return err
-		}
-		if *curApp.State == "STARTED" {
-			// if the app was running before the restage when wait for it to start again
-			if err := am.WaitForAppToStart(app, timeout); err != nil {
+		if err := withAppEvents(am, session.Log, app.ID, func() error {
+			if err := am.RestageApp(app.ID, timeouts.Staging); err != nil {
 				return err
 			}
-		}
-	} else if restart && !d.Get("stopped").(bool) { // only run restart if the final state is running
-		if err := am.StopApp(app.ID, timeout); err != nil {
+			if *curApp.State == "STARTED" {
+				// if the app was running before the restage when wait for it to start again
+				return am.WaitForAppToStart(app, timeouts.Start)
+			}
+			return nil
+		}); err != nil {
 			return err
 		}
-		if err := am.StartApp(app.ID, timeout); err != nil {
+	} else if restart && !d.Get("stopped").(bool) { // only run restart if the final state is running
+		if err := withAppEvents(am, session.Log, app.ID, func() error {
+			if err := am.StopApp(app.ID, timeouts.Stop); err != nil {
+				return err
+			}
+			return am.StartApp(app.ID, timeouts.Start)
+		}); err != nil {
 			return err
 		}
 	}
@@ -1296,11 +2210,11 @@ func resourceAppStandardUpdate(d *schema.ResourceData, meta interface{}, app cfa
 	// now set the final started/stopped state, whatever it is
 	if d.HasChange("stopped") {
 		if d.Get("stopped").(bool) {
-			if err := am.StopApp(app.ID, timeout); err != nil {
+			if err := am.StopApp(app.ID, timeouts.Stop); err != nil {
 				return err
 			}
 		} else {
-			if err := am.StartApp(app.ID, timeout); err != nil {
+			if err := am.StartApp(app.ID, timeouts.Start); err != nil {
 				return err
 			}
 		}
@@ -1409,6 +2323,32 @@ func setAppArguments(app cfapi.CCApp, d *schema.ResourceData) {
 	if app.HealthCheckTimeout != nil || IsImportState(d) {
 		d.Set("health_check_timeout", app.HealthCheckTimeout)
 	}
+	if app.ReadinessHealthCheckType != nil && *app.ReadinessHealthCheckType == "http" {
+		httpHealthCheck := map[string]interface{}{}
+		if app.ReadinessHealthCheckInterval != nil {
+			httpHealthCheck["check_interval"] = *app.ReadinessHealthCheckInterval
+		}
+		if app.ReadinessHealthyThreshold != nil {
+			httpHealthCheck["healthy_threshold"] = *app.ReadinessHealthyThreshold
+		}
+		if app.ReadinessUnhealthyThreshold != nil {
+			httpHealthCheck["unhealthy_threshold"] = *app.ReadinessUnhealthyThreshold
+		}
+		if app.ReadinessHealthCheckHostHeader != nil {
+			httpHealthCheck["request_host_header"] = *app.ReadinessHealthCheckHostHeader
+		}
+		if app.ReadinessHealthCheckBodyRegex != nil {
+			httpHealthCheck["response_body_regex"] = *app.ReadinessHealthCheckBodyRegex
+		}
+		if app.ReadinessHealthCheckStatusCodes != nil {
+			codes := []interface{}{}
+			for _, c := range *app.ReadinessHealthCheckStatusCodes {
+				codes = append(codes, c)
+			}
+			httpHealthCheck["response_status_codes"] = schema.NewSet(resourceIntegerSet, codes)
+		}
+		d.Set("http_health_check", []map[string]interface{}{httpHealthCheck})
+	}
 	if app.Environment != nil || IsImportState(d) {
 		d.Set("environment", app.Environment)
 	}
@@ -1423,6 +2363,288 @@ func setAppArguments(app cfapi.CCApp, d *schema.ResourceData) {
 	d.Set("ports", schema.NewSet(resourceIntegerSet, ports))
 }
 
+// resolveDockerImageDigest resolves the 'docker' block's image/tag to an
+// immutable "repo@sha256:..." reference by issuing a registry HEAD manifest
+// request, applying registry_mirror as the image's host prefix and using
+// docker_credentials for auth. An explicitly set 'pinned_digest' is used as
+// the digest as is, with no registry request. The resolved digest is also returned on its
+// own so it can be stored back into the 'digest' computed attribute.
+// 'digest' itself is never read as a pin here: it is re-resolved on every
+// create/update (and, via resolveDockerDigestDrift, on every plan) so a
+// moved tag is always detected, matching the behavior of an unset
+// 'pinned_digest'.
+func resolveDockerImageDigest(dockerConfig map[string]interface{}, dockerCredentials interface{}) (image, digest string, err error) {
+
+	image = dockerConfig["image"].(string)
+	if mirror, ok := dockerConfig["registry_mirror"].(string); ok && len(mirror) > 0 {
+		image = fmt.Sprintf("%s/%s", strings.TrimSuffix(mirror, "/"), image)
+	}
+
+	if pinned, ok := dockerConfig["pinned_digest"].(string); ok && len(pinned) > 0 {
+		digest = pinned
+	} else {
+		var creds map[string]interface{}
+		if dockerCredentials != nil {
+			creds = dockerCredentials.(map[string]interface{})
+		}
+		if digest, err = cfapi.HeadRegistryManifestDigest(image, creds); err != nil {
+			return "", "", fmt.Errorf("unable to resolve digest for docker image %q: %s", image, err)
+		}
+	}
+
+	if idx := strings.Index(image, "@sha256:"); idx >= 0 {
+		image = image[:idx]
+	}
+	return fmt.Sprintf("%s@%s", image, digest), digest, nil
+}
+
+// resolveDockerDigestDrift re-resolves the 'docker' block's image digest
+// during CustomizeDiff, the same way resolveDockerImageDigest does during
+// create/update, so a tag that moved on the remote registry between
+// applies shows up as drift on the very next plan instead of only ever
+// being resolved once. An explicit 'pinned_digest' opts a config out of
+// this re-resolution, the same as it opts out of it during apply.
+func resolveDockerDigestDrift(diff *schema.ResourceDiff) error {
+	v, ok := diff.GetOk("docker")
+	if !ok {
+		return nil
+	}
+	dockerConfig := v.([]interface{})[0].(map[string]interface{})
+	if pinned, ok := dockerConfig["pinned_digest"].(string); ok && len(pinned) > 0 {
+		return nil
+	}
+
+	_, digest, err := resolveDockerImageDigest(dockerConfig, diff.Get("docker_credentials"))
+	if err != nil {
+		// Don't fail the whole plan over a transient registry lookup; a
+		// hard error here will instead surface from resourceAppCreate/
+		// resourceAppUpdate when the digest is actually needed to apply.
+		return nil
+	}
+
+	if dockerConfig["digest"].(string) != digest {
+		return diff.SetNew("docker.0.digest", digest)
+	}
+	return nil
+}
+
+// hashArtifact returns the sha256 digest of the file or directory tree at
+// path, used to key the content-addressable artifact cache and to populate
+// the 'source_sha256' computed attribute.
+func hashArtifact(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	if info.IsDir() {
+		err = filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
+			if err != nil || fi.IsDir() {
+				return err
+			}
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(h, f)
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		if _, err = io.Copy(h, f); err != nil {
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func artifactCacheConfig(d *schema.ResourceData) (dir string, maxSizeMB int) {
+	dir, maxSizeMB = DefaultArtifactCacheDir, 0
+	if v, ok := d.GetOk("artifact_cache"); ok {
+		cacheConfig := v.([]interface{})[0].(map[string]interface{})
+		dir = cacheConfig["dir"].(string)
+		maxSizeMB = cacheConfig["max_size_mb"].(int)
+	}
+	return dir, maxSizeMB
+}
+
+// sourceCacheKey derives a stable identity for the app's current source
+// config -- the (repo, resolved commit) pair for a git source, or the bare
+// URL for a url source -- that lookupArtifactInCache/recordArtifactInCache
+// index on to recognize "this is the same source" without re-downloading
+// it first to learn its content hash. file:// URLs and docker/github_release
+// sources are not cacheable: the former is already local, and we don't yet
+// resolve the latter to a stable ref the way we do for git.
+func sourceCacheKey(d *schema.ResourceData) (key string, cacheable bool) {
+	if v, ok := d.GetOk("git"); ok {
+		gitConfig := v.([]interface{})[0].(map[string]interface{})
+		url := gitConfig["url"].(string)
+		ref := gitConfig["branch"].(string)
+		if tag, ok := gitConfig["tag"].(string); ok && len(tag) > 0 {
+			ref = tag
+		}
+		commit, err := resolveGitCommit(url, ref)
+		if err != nil {
+			return "", false
+		}
+		return fmt.Sprintf("git:%s@%s", url, commit), true
+	}
+	if v, ok := d.GetOk("url"); ok {
+		url := v.(string)
+		if strings.HasPrefix(url, "file://") {
+			return "", false
+		}
+		return fmt.Sprintf("url:%s", url), true
+	}
+	return "", false
+}
+
+// cacheIndexFilename maps a sourceCacheKey to a filesystem-safe index file
+// name (the key itself may contain '/' and other characters a git URL can
+// carry).
+func cacheIndexFilename(key string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(key)))
+}
+
+// lookupArtifactInCache looks up the content hash previously recorded for
+// sourceKey and, if both the index entry and its cached blob are still
+// present, returns the cached artifact's path and hash so the caller can
+// skip prepareApp's download entirely.
+func lookupArtifactInCache(d *schema.ResourceData, sourceKey string) (path, hash string, ok bool) {
+	dir, _ := artifactCacheConfig(d)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "index", cacheIndexFilename(sourceKey)))
+	if err != nil {
+		return "", "", false
+	}
+	hash = strings.TrimSpace(string(data))
+
+	cachedPath := filepath.Join(dir, hash)
+	if _, err := os.Stat(cachedPath); err != nil {
+		return "", "", false
+	}
+	return cachedPath, hash, true
+}
+
+// recordArtifactInCache best-effort copies the prepared app artifact into
+// the local cache directory under its content hash, and indexes sourceKey
+// against that hash, so a future plan against the same source recognizes
+// it via lookupArtifactInCache without re-downloading. Once the cache
+// exceeds max_size_mb, the oldest blobs are evicted to make room; stale
+// index entries left pointing at an evicted blob are harmless; the next
+// lookup simply misses.
+func recordArtifactInCache(d *schema.ResourceData, appPath, hash, sourceKey string) error {
+	dir, maxSizeMB := artifactCacheConfig(d)
+	indexDir := filepath.Join(dir, "index")
+	if err := os.MkdirAll(indexDir, 0755); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(appPath)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return nil // only single-file artifacts are cached for now
+	}
+
+	cachedPath := filepath.Join(dir, hash)
+	if _, err := os.Stat(cachedPath); err != nil {
+		in, err := os.Open(appPath)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.Create(cachedPath)
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(indexDir, cacheIndexFilename(sourceKey)), []byte(hash), 0644); err != nil {
+		return err
+	}
+
+	return evictArtifactCache(dir, maxSizeMB)
+}
+
+// evictArtifactCache removes the oldest cached blobs until the cache
+// directory's total size is back under maxSizeMB. maxSizeMB <= 0 means
+// unlimited, matching the 'max_size_mb' schema default.
+func evictArtifactCache(dir string, maxSizeMB int) error {
+	if maxSizeMB <= 0 {
+		return nil
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var blobs []os.FileInfo
+	var total int64
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue // skip the "index" subdirectory
+		}
+		blobs = append(blobs, entry)
+		total += entry.Size()
+	}
+
+	budget := int64(maxSizeMB) * 1024 * 1024
+	if total <= budget {
+		return nil
+	}
+
+	sort.Slice(blobs, func(i, j int) bool {
+		return blobs[i].ModTime().Before(blobs[j].ModTime())
+	})
+
+	for _, blob := range blobs {
+		if total <= budget {
+			break
+		}
+		if err := os.Remove(filepath.Join(dir, blob.Name())); err != nil {
+			return err
+		}
+		total -= blob.Size()
+	}
+	return nil
+}
+
+// resolveGitCommit resolves a git branch/tag to a commit SHA via a cheap
+// 'git ls-remote', so that plans do not report spurious diffs when a
+// tracking branch such as 'master' has not actually advanced.
+func resolveGitCommit(url, ref string) (string, error) {
+	out, err := exec.Command("git", "ls-remote", url, ref).Output()
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve git ref %q on %q: %s", ref, url, err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("git ref %q not found on %q", ref, url)
+	}
+	return fields[0], nil
+}
+
 func prepareApp(app cfapi.CCApp, d *schema.ResourceData, log *cfapi.Logger) (path string, err error) {
 
 	if v, ok := d.GetOk("url"); ok {
@@ -1462,6 +2684,20 @@ func prepareApp(app cfapi.CCApp, d *schema.ResourceData, log *cfapi.Logger) (pat
 	} else {
 		log.UI.Say("Retrieving application %s source / binary.", terminal.EntityNameColor(app.Name))
 
+		if v, ok := d.GetOk("git"); ok {
+			gitConfig := v.([]interface{})[0].(map[string]interface{})
+			ref := gitConfig["branch"].(string)
+			if tag, ok := gitConfig["tag"].(string); ok && len(tag) > 0 {
+				ref = tag
+			}
+			if commit, err := resolveGitCommit(gitConfig["url"].(string), ref); err == nil {
+				gitConfig["commit"] = commit
+				d.Set("git", []map[string]interface{}{gitConfig})
+			} else {
+				log.DebugMessage("Unable to resolve git ref %q to a commit, proceeding anyway: %s", ref, err)
+			}
+		}
+
 		var repository repo.Repository
 		if repository, err = getRepositoryFromConfig(d); err != nil {
 			return path, err
@@ -1481,6 +2717,143 @@ func prepareApp(app cfapi.CCApp, d *schema.ResourceData, log *cfapi.Logger) (pat
 	return path, nil
 }
 
+// maxAttachedEvents bounds how many recent CF events get appended to an
+// operation error, so a long restage/restart failure doesn't dump its
+// entire event history into the Terraform diagnostic.
+const maxAttachedEvents = 10
+
+// withAppEvents subscribes to app's event feed for the duration of op,
+// logging every event through log at INFO as it arrives so push/restage/
+// restart/scale operations are no longer opaque while they run. If op
+// fails, the most recent events (and any crash/staging-failure events
+// specifically) are appended to the returned error so 'terraform apply'
+// surfaces actionable context instead of a bare timeout or 500.
+func withAppEvents(am *cfapi.AppManager, log *cfapi.Logger, appID string, op func() error) error {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := am.StreamAppEvents(ctx, appID)
+	if err != nil {
+		// Event streaming is a best-effort diagnostic aid; do not fail the
+		// underlying operation just because the event feed could not open.
+		log.DebugMessage("Unable to open event stream for app %s, proceeding without it: %s", appID, err)
+		return op()
+	}
+
+	go func() {
+		for event := range stream.Events {
+			log.UI.Say("%s", event.String())
+		}
+	}()
+
+	opErr := op()
+	stream.Close()
+
+	if opErr == nil {
+		return nil
+	}
+
+	recent := stream.Recent(maxAttachedEvents)
+	if len(recent) == 0 {
+		return opErr
+	}
+	msg := fmt.Sprintf("%s\nrecent CF events:\n%s", opErr, formatAppEvents(recent))
+	if failures := stream.Failures(maxAttachedEvents); len(failures) > 0 {
+		msg += "\ncrash/staging-failure events among them:\n" + formatAppEvents(failures)
+	}
+	return fmt.Errorf("%s", msg)
+}
+
+func formatAppEvents(events []cfapi.CCEvent) string {
+	lines := make([]string, len(events))
+	for i, e := range events {
+		lines[i] = "  " + e.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// runValidationProbes executes every 'validation' probe against the staged
+// app before any live route mapping is moved over to it. Each probe is
+// retried up to 'retries' times, 'interval' apart, inside its own overall
+// 'timeout', after an optional 'initial_delay' to let the app settle.
+func runValidationProbes(log *cfapi.Logger, probes []map[string]interface{}) error {
+
+	for _, p := range probes {
+		url := p["url"].(string)
+		expectedStatus := p["expected_status"].(int)
+		retries := p["retries"].(int)
+
+		initialDelay, err := time.ParseDuration(p["initial_delay"].(string))
+		if err != nil {
+			return fmt.Errorf("invalid validation.initial_delay: %s", err)
+		}
+		interval, err := time.ParseDuration(p["interval"].(string))
+		if err != nil {
+			return fmt.Errorf("invalid validation.interval: %s", err)
+		}
+		timeout, err := time.ParseDuration(p["timeout"].(string))
+		if err != nil {
+			return fmt.Errorf("invalid validation.timeout: %s", err)
+		}
+
+		var bodyRegex *regexp.Regexp
+		if v, ok := p["body_regex"].(string); ok && len(v) > 0 {
+			if bodyRegex, err = regexp.Compile(v); err != nil {
+				return fmt.Errorf("invalid validation.body_regex: %s", err)
+			}
+		}
+
+		if initialDelay > 0 {
+			time.Sleep(initialDelay)
+		}
+
+		deadline := time.Now().Add(timeout)
+		var lastErr error
+		for attempt := 0; attempt <= retries; attempt++ {
+			if time.Now().After(deadline) {
+				break
+			}
+			lastErr = probeOnce(url, expectedStatus, bodyRegex)
+			if lastErr == nil {
+				log.DebugMessage("Validation probe against %s passed on attempt %d", url, attempt+1)
+				break
+			}
+			log.DebugMessage("Validation probe against %s failed on attempt %d: %s", url, attempt+1, lastErr)
+			if attempt < retries {
+				time.Sleep(interval)
+			}
+		}
+		if lastErr != nil {
+			return fmt.Errorf("validation probe against %s did not pass before cutover: %s", url, lastErr)
+		}
+	}
+	return nil
+}
+
+func probeOnce(url string, expectedStatus int, bodyRegex *regexp.Regexp) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatus {
+		return fmt.Errorf("expected status %d, got %d", expectedStatus, resp.StatusCode)
+	}
+
+	if bodyRegex != nil {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		if !bodyRegex.Match(body) {
+			return fmt.Errorf("response body did not match %q", bodyRegex.String())
+		}
+	}
+	return nil
+}
+
 func validateRoute(routeConfig map[string]interface{}, route string, appID string, rm *cfapi.RouteManager) (routeID string, err error) {
 
 	if v, ok := routeConfig[route]; ok {
@@ -1502,6 +2875,64 @@ func validateRoute(routeConfig map[string]interface{}, route string, appID strin
 	return routeID, err
 }
 
+// validateRouteWeights enforces that the 'weight' values declared across
+// 'routes' entries sharing the same 'route' never sum to more than 100.
+// This only catches conflicts declared within this resource's own 'routes'
+// set -- a route shared with destinations managed by other resources or
+// 'cloudfoundry_route_mapping' instances cannot be seen at plan time here.
+func validateRouteWeights(diff *schema.ResourceDiff) error {
+
+	v, ok := diff.GetOk("routes")
+	if !ok {
+		return nil
+	}
+
+	weightByRoute := map[string]int{}
+	for _, raw := range v.(*schema.Set).List() {
+		route := raw.(map[string]interface{})
+		routeID := route["route"].(string)
+		weight, ok := route["weight"].(int)
+		if !ok || weight == 0 {
+			continue
+		}
+		weightByRoute[routeID] += weight
+	}
+
+	for routeID, total := range weightByRoute {
+		if total > 100 {
+			return fmt.Errorf("route %s: 'routes' weights sum to %d, which exceeds 100", routeID, total)
+		}
+	}
+	return nil
+}
+
+// createRouteMappingFromData creates a route mapping for one 'routes' set
+// element, going through rm.CreateRouteDestination when the element
+// declares a 'weight' or 'protocol' (since the plain CreateRouteMapping
+// call has no way to carry them) and falling back to the simple call
+// otherwise.
+func createRouteMappingFromData(rm *cfapi.RouteManager, routeID, appID string, data map[string]interface{}) (string, error) {
+
+	weight, hasWeight := data["weight"].(int)
+	protocol, hasProtocol := data["protocol"].(string)
+
+	if (!hasWeight || weight == 0) && (!hasProtocol || len(protocol) == 0) {
+		return rm.CreateRouteMapping(routeID, appID, nil)
+	}
+
+	dest := cfapi.RouteDestination{
+		RouteGUID: routeID,
+		AppGUID:   appID,
+	}
+	if hasWeight && weight > 0 {
+		dest.Weight = &weight
+	}
+	if hasProtocol && len(protocol) > 0 {
+		dest.Protocol = protocol
+	}
+	return rm.CreateRouteDestination(dest)
+}
+
 func updateAppRouteMappings(
 	old map[string]interface{},
 	new map[string]interface{},
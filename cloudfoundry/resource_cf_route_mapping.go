@@ -0,0 +1,172 @@
+package cloudfoundry
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/terraform-providers/terraform-provider-cloudfoundry/cloudfoundry/cfapi"
+)
+
+// resourceRouteMapping splits the 'routes' set on 'cloudfoundry_app' out
+// into its own resource, following the decomposition pattern used for the
+// GCE load-balancing resources (target pool / forwarding rule / health
+// check as separate resources). This lets a single route be mapped to more
+// than one app, and lets routes and their mappings be managed from
+// different modules/workspaces without one app's 'routes' set silently
+// stealing a route bound elsewhere.
+func resourceRouteMapping() *schema.Resource {
+
+	return &schema.Resource{
+
+		Create: resourceRouteMappingCreate,
+		Read:   resourceRouteMappingRead,
+		Update: resourceRouteMappingUpdate,
+		Delete: resourceRouteMappingDelete,
+
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"app_id": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"route_id": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"port": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IntBetween(1, 65535),
+			},
+			"weight": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntBetween(1, 100),
+			},
+			"mapping_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceRouteMappingCreate(d *schema.ResourceData, meta interface{}) error {
+
+	session := meta.(*cfapi.Session)
+	if session == nil {
+		return fmt.Errorf("client is nil")
+	}
+	rm := session.RouteManager()
+
+	appID := d.Get("app_id").(string)
+	routeID := d.Get("route_id").(string)
+
+	var port *int
+	if v, ok := d.GetOk("port"); ok {
+		vv := v.(int)
+		port = &vv
+	}
+
+	var mappingID string
+	var err error
+	if v, ok := d.GetOk("weight"); ok {
+		weight := v.(int)
+		mappingID, err = rm.CreateRouteDestination(cfapi.RouteDestination{
+			RouteGUID: routeID,
+			AppGUID:   appID,
+			Port:      port,
+			Weight:    &weight,
+		})
+	} else {
+		mappingID, err = rm.CreateRouteMapping(routeID, appID, port)
+	}
+	if err != nil {
+		return err
+	}
+
+	d.SetId(mappingID)
+	d.Set("mapping_id", mappingID)
+
+	return resourceRouteMappingRead(d, meta)
+}
+
+// resourceRouteMappingUpdate PATCHes the route destination's weight in
+// place via rm.UpdateRouteDestination instead of the destroy/recreate
+// ForceNew used to force, so a weight rebalance does not drop the route's
+// traffic while the mapping is torn down and rebuilt.
+func resourceRouteMappingUpdate(d *schema.ResourceData, meta interface{}) error {
+
+	session := meta.(*cfapi.Session)
+	if session == nil {
+		return fmt.Errorf("client is nil")
+	}
+	rm := session.RouteManager()
+
+	var weight *int
+	if v, ok := d.GetOk("weight"); ok {
+		vv := v.(int)
+		weight = &vv
+	}
+
+	if err := rm.UpdateRouteDestination(d.Id(), weight, ""); err != nil {
+		return err
+	}
+
+	return resourceRouteMappingRead(d, meta)
+}
+
+func resourceRouteMappingRead(d *schema.ResourceData, meta interface{}) error {
+
+	session := meta.(*cfapi.Session)
+	if session == nil {
+		return fmt.Errorf("client is nil")
+	}
+	rm := session.RouteManager()
+
+	mapping, err := rm.ReadRouteMapping(d.Id())
+	if err != nil {
+		if strings.Contains(err.Error(), "status code: 404") {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("app_id", mapping.AppGUID)
+	d.Set("route_id", mapping.RouteGUID)
+	d.Set("port", mapping.AppPort)
+	if mapping.Weight != nil {
+		d.Set("weight", *mapping.Weight)
+	}
+	d.Set("mapping_id", mapping.GUID)
+
+	return nil
+}
+
+func resourceRouteMappingDelete(d *schema.ResourceData, meta interface{}) error {
+
+	session := meta.(*cfapi.Session)
+	if session == nil {
+		return fmt.Errorf("client is nil")
+	}
+	rm := session.RouteManager()
+
+	if err := rm.DeleteRouteMapping(d.Id()); err != nil {
+		if !strings.Contains(err.Error(), "status code: 404") {
+			return err
+		}
+	}
+	return nil
+}
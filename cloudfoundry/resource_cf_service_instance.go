@@ -0,0 +1,254 @@
+package cloudfoundry
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/terraform-providers/terraform-provider-cloudfoundry/cloudfoundry/cfapi"
+)
+
+// DefaultServiceInstanceTimeout is how long to wait for a broker's async
+// last_operation to settle when no 'timeouts' block is given.
+const DefaultServiceInstanceTimeout = 60 * time.Minute
+
+func resourceServiceInstance() *schema.Resource {
+
+	return &schema.Resource{
+
+		Create: resourceServiceInstanceCreate,
+		Read:   resourceServiceInstanceRead,
+		Update: resourceServiceInstanceUpdate,
+		Delete: resourceServiceInstanceDelete,
+
+		Importer: &schema.ResourceImporter{
+			State: resourceServiceInstanceImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"space": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"service_plan": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"tags": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			// json_params carries arbitrary broker-specific provisioning /
+			// update parameters as a raw JSON object. It is not diffed
+			// field-by-field since the broker defines its own shape; any
+			// change simply triggers an update-in-place, never a
+			// destroy/recreate, since the instance identity does not
+			// depend on its parameters.
+			"json_params": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.ValidateJsonString,
+			},
+			"timeouts": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"create": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "60m",
+						},
+						"update": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "60m",
+						},
+						"delete": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "60m",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func serviceInstanceTimeout(d *schema.ResourceData, key string) time.Duration {
+	if v, ok := d.GetOk("timeouts"); ok {
+		timeoutConfig := v.([]interface{})[0].(map[string]interface{})
+		if s, ok := timeoutConfig[key].(string); ok && len(s) > 0 {
+			if parsed, err := time.ParseDuration(s); err == nil {
+				return parsed
+			}
+		}
+	}
+	return DefaultServiceInstanceTimeout
+}
+
+func tagsFromConfig(v interface{}) []string {
+	raw := v.([]interface{})
+	tags := make([]string, len(raw))
+	for i, t := range raw {
+		tags[i] = t.(string)
+	}
+	return tags
+}
+
+func waitForServiceInstance(sm *cfapi.ServiceManager, guid string, timeout time.Duration) error {
+	waiter := cfapi.AppOperationWaiter{
+		RefreshFunc:  cfapi.ServiceInstanceStateRefresh(sm, guid),
+		Pending:      []string{"in progress"},
+		Target:       []string{"succeeded"},
+		Timeout:      timeout,
+		Delay:        2 * time.Second,
+		MinTimeout:   2 * time.Second,
+		PollInterval: 5 * time.Second,
+	}
+	_, err := waiter.Wait()
+	return err
+}
+
+func resourceServiceInstanceCreate(d *schema.ResourceData, meta interface{}) error {
+
+	session := meta.(*cfapi.Session)
+	if session == nil {
+		return fmt.Errorf("client is nil")
+	}
+	sm := session.ServiceManager()
+
+	name := d.Get("name").(string)
+	space := d.Get("space").(string)
+	servicePlan := d.Get("service_plan").(string)
+	tags := tagsFromConfig(d.Get("tags"))
+	params := d.Get("json_params").(string)
+
+	instance, err := sm.CreateServiceInstance(name, space, servicePlan, tags, params)
+	if err != nil {
+		return err
+	}
+	d.SetId(instance.GUID)
+
+	if err := waitForServiceInstance(sm, instance.GUID, serviceInstanceTimeout(d, "create")); err != nil {
+		return err
+	}
+
+	return resourceServiceInstanceRead(d, meta)
+}
+
+func resourceServiceInstanceRead(d *schema.ResourceData, meta interface{}) error {
+
+	session := meta.(*cfapi.Session)
+	if session == nil {
+		return fmt.Errorf("client is nil")
+	}
+	sm := session.ServiceManager()
+
+	instance, err := sm.ReadServiceInstance(d.Id())
+	if err != nil {
+		if strings.Contains(err.Error(), "status code: 404") {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("name", instance.Name)
+	d.Set("space", instance.SpaceGUID)
+	d.Set("service_plan", instance.ServicePlanGUID)
+	d.Set("tags", instance.Tags)
+
+	return nil
+}
+
+// resourceServiceInstanceImport adopts an existing brokered instance into
+// state by its GUID. If the instance's plan has since been pulled from
+// the marketplace, 'service_plan' is left blank with a warning logged
+// instead of failing the import outright -- the instance itself is still
+// perfectly usable, just no longer provisionable from this plan.
+func resourceServiceInstanceImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+
+	session := meta.(*cfapi.Session)
+	if session == nil {
+		return nil, fmt.Errorf("client is nil")
+	}
+	sm := session.ServiceManager()
+
+	instance, err := sm.ReadServiceInstance(d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set("name", instance.Name)
+	d.Set("space", instance.SpaceGUID)
+	d.Set("tags", instance.Tags)
+
+	if len(instance.ServicePlanGUID) > 0 {
+		if _, err := sm.ReadServicePlan(instance.ServicePlanGUID); err != nil {
+			session.Log.UI.Say("Warning: plan %s for imported service instance %s was not found in the marketplace; leaving 'service_plan' blank in state.", instance.ServicePlanGUID, d.Id())
+		} else {
+			d.Set("service_plan", instance.ServicePlanGUID)
+		}
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceServiceInstanceUpdate(d *schema.ResourceData, meta interface{}) error {
+
+	session := meta.(*cfapi.Session)
+	if session == nil {
+		return fmt.Errorf("client is nil")
+	}
+	sm := session.ServiceManager()
+
+	name := d.Get("name").(string)
+	servicePlan := d.Get("service_plan").(string)
+	tags := tagsFromConfig(d.Get("tags"))
+	params := d.Get("json_params").(string)
+
+	if _, err := sm.UpdateServiceInstance(d.Id(), name, servicePlan, tags, params); err != nil {
+		if d.HasChange("service_plan") && strings.Contains(err.Error(), "PlanNotUpdateable") {
+			return fmt.Errorf("service plan for '%s' cannot be changed in place: the current or target plan does not allow upgrade/downgrade (CF error: %s)", name, err)
+		}
+		return err
+	}
+
+	if err := waitForServiceInstance(sm, d.Id(), serviceInstanceTimeout(d, "update")); err != nil {
+		return err
+	}
+
+	return resourceServiceInstanceRead(d, meta)
+}
+
+func resourceServiceInstanceDelete(d *schema.ResourceData, meta interface{}) error {
+
+	session := meta.(*cfapi.Session)
+	if session == nil {
+		return fmt.Errorf("client is nil")
+	}
+	sm := session.ServiceManager()
+
+	if err := sm.DeleteServiceInstance(d.Id()); err != nil {
+		if strings.Contains(err.Error(), "status code: 404") {
+			return nil
+		}
+		return err
+	}
+
+	return waitForServiceInstance(sm, d.Id(), serviceInstanceTimeout(d, "delete"))
+}
@@ -8,7 +8,7 @@ import (
 
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/terraform"
-	"github.com/terraform-providers/terraform-provider-cf/cloudfoundry/cfapi"
+	"github.com/terraform-providers/terraform-provider-cloudfoundry/cloudfoundry/cfapi"
 )
 
 const serviceInstanceResourceCreate = `
@@ -53,6 +53,28 @@ resource "cf_service_instance" "mysql" {
 }
 `
 
+const serviceInstanceResourceUpdateParams = `
+
+data "cf_org" "org" {
+    name = "pcfdev-org"
+}
+data "cf_space" "space" {
+    name = "pcfdev-space"
+	org = "${data.cf_org.org.id}"
+}
+data "cf_service" "mysql" {
+    name = "p-mysql"
+}
+
+resource "cf_service_instance" "mysql" {
+	name = "mysql-updated"
+    space = "${data.cf_space.space.id}"
+    service_plan = "${data.cf_service.mysql.service_plans["100mb"]}"
+	tags = [ "tag-2", "tag-3", "tag-4" ]
+	json_params = "{\"ram_mb\": 256}"
+}
+`
+
 const serviceInstanceResourceCreateRedis = `
 
 data "cf_org" "org" {
@@ -78,6 +100,97 @@ resource "cf_service_instance" "redis" {
 }
 `
 
+const serviceKeyResourceCreate = `
+
+data "cf_org" "org" {
+    name = "pcfdev-org"
+}
+data "cf_space" "space" {
+    name = "pcfdev-space"
+	org = "${data.cf_org.org.id}"
+}
+data "cf_service" "mysql" {
+    name = "p-mysql"
+}
+
+resource "cf_service_instance" "mysql" {
+	name = "mysql"
+    space = "${data.cf_space.space.id}"
+    service_plan = "${data.cf_service.mysql.service_plans["100mb"]}"
+}
+
+resource "cf_service_key" "mysql-key" {
+	name = "mysql-key"
+	service_instance = "${cf_service_instance.mysql.id}"
+}
+`
+
+const userProvidedServiceResourceCreate = `
+
+data "cf_org" "org" {
+    name = "pcfdev-org"
+}
+data "cf_space" "space" {
+    name = "pcfdev-space"
+	org = "${data.cf_org.org.id}"
+}
+
+resource "cf_user_provided_service" "ups" {
+	name = "ups"
+    space = "${data.cf_space.space.id}"
+	credentials = {
+		uri = "https://example.com/original"
+	}
+	tags = [ "tag-1" ]
+}
+`
+
+const userProvidedServiceResourceUpdate = `
+
+data "cf_org" "org" {
+    name = "pcfdev-org"
+}
+data "cf_space" "space" {
+    name = "pcfdev-space"
+	org = "${data.cf_org.org.id}"
+}
+
+resource "cf_user_provided_service" "ups" {
+	name = "ups"
+    space = "${data.cf_space.space.id}"
+	credentials = {
+		uri = "https://example.com/updated"
+	}
+	tags = [ "tag-1", "tag-2" ]
+}
+`
+
+const serviceInstanceResourceCreateRedisLargePlan = `
+
+data "cf_org" "org" {
+    name = "pcfdev-org"
+}
+data "cf_space" "space" {
+    name = "pcfdev-space"
+	org = "${data.cf_org.org.id}"
+}
+data "cf_service" "redis" {
+    name = "p.redis"
+}
+
+resource "cf_service_instance" "redis" {
+	name = "redis"
+    space = "${data.cf_space.space.id}"
+    service_plan = "${data.cf_service.redis.service_plans["cache-large"]}"
+	tags = [ "tag-1" , "tag-2" ]
+    timeouts {
+      create = "30m"
+      update = "30m"
+      delete = "30m"
+    }
+}
+`
+
 const serviceInstanceResourceAsyncCreate = `
 
 data "cf_domain" "fake-service-broker-domain" {
@@ -136,6 +249,7 @@ resource "cf_service_instance" "fake-service" {
 func TestAccServiceInstance_normal(t *testing.T) {
 
 	ref := "cf_service_instance.mysql"
+	var idBeforeParamsUpdate string
 	resource.Test(t,
 		resource.TestCase{
 			PreCheck:     func() { testAccPreCheck(t) },
@@ -174,6 +288,23 @@ func TestAccServiceInstance_normal(t *testing.T) {
 							ref, "tags.2", "tag-4"),
 					),
 				},
+
+				resource.TestStep{
+					Config: serviceInstanceResourceUpdate,
+					Check: resource.ComposeTestCheckFunc(
+						testAccCaptureID(ref, &idBeforeParamsUpdate),
+					),
+				},
+
+				resource.TestStep{
+					Config: serviceInstanceResourceUpdateParams,
+					Check: resource.ComposeTestCheckFunc(
+						testAccCheckServiceInstanceExists(ref),
+						testAccCheckIDUnchanged(ref, &idBeforeParamsUpdate),
+						resource.TestCheckResourceAttr(
+							ref, "json_params", "{\"ram_mb\": 256}"),
+					),
+				},
 			},
 		})
 }
@@ -208,6 +339,125 @@ func TestAccServiceInstance_async(t *testing.T) {
 		})
 }
 
+func TestAccServiceKey_normal(t *testing.T) {
+
+	ref := "cf_service_key.mysql-key"
+
+	resource.Test(t,
+		resource.TestCase{
+			PreCheck:     func() { testAccPreCheck(t) },
+			Providers:    testAccProviders,
+			CheckDestroy: testAccCheckServiceInstanceDestroyed([]string{"mysql"}, "data.cf_space.space"),
+			Steps: []resource.TestStep{
+
+				resource.TestStep{
+					Config: serviceKeyResourceCreate,
+					Check: resource.ComposeTestCheckFunc(
+						testAccCheckServiceKeyExists(ref),
+						resource.TestCheckResourceAttr(
+							ref, "name", "mysql-key"),
+						resource.TestCheckResourceAttrSet(
+							ref, "credentials.uri"),
+					),
+				},
+			},
+		})
+}
+
+func TestAccUserProvidedService_normal(t *testing.T) {
+
+	ref := "cf_user_provided_service.ups"
+
+	resource.Test(t,
+		resource.TestCase{
+			PreCheck:     func() { testAccPreCheck(t) },
+			Providers:    testAccProviders,
+			CheckDestroy: testAccCheckServiceInstanceDestroyed([]string{"ups"}, "data.cf_space.space"),
+			Steps: []resource.TestStep{
+
+				resource.TestStep{
+					Config: userProvidedServiceResourceCreate,
+					Check: resource.ComposeTestCheckFunc(
+						testAccCheckServiceInstanceExists(ref),
+						resource.TestCheckResourceAttr(
+							ref, "credentials.uri", "https://example.com/original"),
+						resource.TestCheckResourceAttr(
+							ref, "tags.#", "1"),
+					),
+				},
+
+				resource.TestStep{
+					Config: userProvidedServiceResourceUpdate,
+					Check: resource.ComposeTestCheckFunc(
+						testAccCheckServiceInstanceExists(ref),
+						resource.TestCheckResourceAttr(
+							ref, "credentials.uri", "https://example.com/updated"),
+						resource.TestCheckResourceAttr(
+							ref, "tags.#", "2"),
+					),
+				},
+			},
+		})
+}
+
+func TestAccServiceInstance_import(t *testing.T) {
+
+	ref := "cf_service_instance.mysql"
+
+	resource.Test(t,
+		resource.TestCase{
+			PreCheck:     func() { testAccPreCheck(t) },
+			Providers:    testAccProviders,
+			CheckDestroy: testAccCheckServiceInstanceDestroyed([]string{"mysql"}, "data.cf_space.space"),
+			Steps: []resource.TestStep{
+
+				resource.TestStep{
+					Config: serviceInstanceResourceCreate,
+					Check: resource.ComposeTestCheckFunc(
+						testAccCheckServiceInstanceExists(ref),
+					),
+				},
+
+				resource.TestStep{
+					ResourceName:      ref,
+					ImportState:       true,
+					ImportStateVerify: true,
+				},
+			},
+		})
+}
+
+func TestAccServiceInstance_planUpdate(t *testing.T) {
+
+	ref := "cf_service_instance.redis"
+	var idBeforePlanChange string
+
+	resource.Test(t,
+		resource.TestCase{
+			PreCheck:     func() { testAccPreCheck(t) },
+			Providers:    testAccProviders,
+			CheckDestroy: testAccCheckServiceInstanceDestroyed([]string{"redis"}, "data.cf_space.space"),
+			Steps: []resource.TestStep{
+
+				resource.TestStep{
+					Config: serviceInstanceResourceCreateRedis,
+					Check: resource.ComposeTestCheckFunc(
+						testAccCheckServiceInstanceExists(ref),
+						testAccCaptureID(ref, &idBeforePlanChange),
+					),
+				},
+
+				resource.TestStep{
+					Config: serviceInstanceResourceCreateRedisLargePlan,
+					Check: resource.ComposeTestCheckFunc(
+						testAccCheckServiceInstanceExists(ref),
+						testAccCheckIDUnchanged(ref, &idBeforePlanChange),
+					),
+				},
+			},
+		})
+}
+
 func TestAccServiceBroker_async(t *testing.T) {
 
 	ref := "cf_service_instance.redis"
@@ -248,6 +498,30 @@ func TestAccServiceBroker_async(t *testing.T) {
 
 
 
+func testAccCaptureID(resourceName string, id *string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("resource '%s' not found in terraform state", resourceName)
+		}
+		*id = rs.Primary.ID
+		return nil
+	}
+}
+
+func testAccCheckIDUnchanged(resourceName string, id *string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("resource '%s' not found in terraform state", resourceName)
+		}
+		if rs.Primary.ID != *id {
+			return fmt.Errorf("expected '%s' to keep id '%s' across an in-place update, got '%s'", resourceName, *id, rs.Primary.ID)
+		}
+		return nil
+	}
+}
+
 func testAccCheckServiceInstanceExists(resource string) resource.TestCheckFunc {
 
 	return func(s *terraform.State) (err error) {
@@ -281,6 +555,36 @@ func testAccCheckServiceInstanceExists(resource string) resource.TestCheckFunc {
 	}
 }
 
+func testAccCheckServiceKeyExists(resource string) resource.TestCheckFunc {
+
+	return func(s *terraform.State) (err error) {
+
+		session := testAccProvider.Meta().(*cfapi.Session)
+
+		rs, ok := s.RootModule().Resources[resource]
+		if !ok {
+			return fmt.Errorf("service key '%s' not found in terraform state", resource)
+		}
+
+		id := rs.Primary.ID
+
+		sm := session.ServiceManager()
+		key, err := sm.ReadServiceKey(id)
+		if err != nil {
+			return
+		}
+		session.Log.DebugMessage(
+			"retrieved service key for resource '%s' with id '%s': %# v",
+			resource, id, key)
+
+		if len(key.Credentials) == 0 {
+			return fmt.Errorf("service key '%s' has no credentials", resource)
+		}
+
+		return
+	}
+}
+
 func testAccCheckServiceInstanceDestroyed(names []string, spaceResource string) resource.TestCheckFunc {
 
 	return func(s *terraform.State) error {
@@ -0,0 +1,156 @@
+package cloudfoundry
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/terraform-providers/terraform-provider-cloudfoundry/cloudfoundry/cfapi"
+)
+
+// resourceServiceKey manages a CF service key: broker-issued credentials
+// for a service instance, for use by apps or tooling outside of CF's own
+// service binding mechanism. CF does not support updating a service key in
+// place, so every field forces a new resource.
+func resourceServiceKey() *schema.Resource {
+
+	return &schema.Resource{
+
+		Create: resourceServiceKeyCreate,
+		Read:   resourceServiceKeyRead,
+		Delete: resourceServiceKeyDelete,
+
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"service_instance": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"params": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.ValidateJsonString,
+			},
+			"credentials": &schema.Schema{
+				Type:      schema.TypeMap,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"timeouts": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				ForceNew: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"create": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "60m",
+						},
+						"delete": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "60m",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourceServiceKeyCreate(d *schema.ResourceData, meta interface{}) error {
+
+	session := meta.(*cfapi.Session)
+	if session == nil {
+		return fmt.Errorf("client is nil")
+	}
+	sm := session.ServiceManager()
+
+	name := d.Get("name").(string)
+	serviceInstance := d.Get("service_instance").(string)
+	params := d.Get("params").(string)
+
+	key, err := sm.CreateServiceKey(name, serviceInstance, params)
+	if err != nil {
+		return err
+	}
+	d.SetId(key.GUID)
+
+	if err := waitForServiceKey(sm, key.GUID, serviceInstanceTimeout(d, "create")); err != nil {
+		return err
+	}
+
+	return resourceServiceKeyRead(d, meta)
+}
+
+func resourceServiceKeyRead(d *schema.ResourceData, meta interface{}) error {
+
+	session := meta.(*cfapi.Session)
+	if session == nil {
+		return fmt.Errorf("client is nil")
+	}
+	sm := session.ServiceManager()
+
+	key, err := sm.ReadServiceKey(d.Id())
+	if err != nil {
+		if strings.Contains(err.Error(), "status code: 404") {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("name", key.Name)
+	d.Set("service_instance", key.ServiceInstanceGUID)
+	d.Set("credentials", key.Credentials)
+
+	return nil
+}
+
+func resourceServiceKeyDelete(d *schema.ResourceData, meta interface{}) error {
+
+	session := meta.(*cfapi.Session)
+	if session == nil {
+		return fmt.Errorf("client is nil")
+	}
+	sm := session.ServiceManager()
+
+	if err := sm.DeleteServiceKey(d.Id()); err != nil {
+		if strings.Contains(err.Error(), "status code: 404") {
+			return nil
+		}
+		return err
+	}
+
+	return waitForServiceKey(sm, d.Id(), serviceInstanceTimeout(d, "delete"))
+}
+
+func waitForServiceKey(sm *cfapi.ServiceManager, guid string, timeout time.Duration) error {
+	waiter := cfapi.AppOperationWaiter{
+		RefreshFunc:  cfapi.ServiceKeyStateRefresh(sm, guid),
+		Pending:      []string{"in progress"},
+		Target:       []string{"succeeded"},
+		Timeout:      timeout,
+		Delay:        2 * time.Second,
+		MinTimeout:   2 * time.Second,
+		PollInterval: 5 * time.Second,
+	}
+	_, err := waiter.Wait()
+	return err
+}
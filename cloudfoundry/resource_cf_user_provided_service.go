@@ -0,0 +1,160 @@
+package cloudfoundry
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/terraform-providers/terraform-provider-cloudfoundry/cloudfoundry/cfapi"
+)
+
+// resourceUserProvidedService manages a CF user-provided service instance:
+// a stand-in for an external database or SaaS dependency that has no
+// broker or plan, so apps can bind to it the same way they bind to a
+// broker-backed cf_service_instance. Unlike cf_service_instance, updates
+// are synchronous -- CF does not run an async last_operation for UPS
+// instances -- so there is no polling here.
+func resourceUserProvidedService() *schema.Resource {
+
+	return &schema.Resource{
+
+		Create: resourceUserProvidedServiceCreate,
+		Read:   resourceUserProvidedServiceRead,
+		Update: resourceUserProvidedServiceUpdate,
+		Delete: resourceUserProvidedServiceDelete,
+
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"space": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"credentials": &schema.Schema{
+				Type:      schema.TypeMap,
+				Optional:  true,
+				Sensitive: true,
+			},
+			"syslog_drain_url": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"route_service_url": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"tags": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func resourceUserProvidedServiceCreate(d *schema.ResourceData, meta interface{}) error {
+
+	session := meta.(*cfapi.Session)
+	if session == nil {
+		return fmt.Errorf("client is nil")
+	}
+	sm := session.ServiceManager()
+
+	instance, err := sm.CreateUserProvidedServiceInstance(
+		d.Get("name").(string),
+		d.Get("space").(string),
+		credentialsFromConfig(d.Get("credentials")),
+		d.Get("syslog_drain_url").(string),
+		d.Get("route_service_url").(string),
+		tagsFromConfig(d.Get("tags")),
+	)
+	if err != nil {
+		return err
+	}
+	d.SetId(instance.GUID)
+
+	return resourceUserProvidedServiceRead(d, meta)
+}
+
+func resourceUserProvidedServiceRead(d *schema.ResourceData, meta interface{}) error {
+
+	session := meta.(*cfapi.Session)
+	if session == nil {
+		return fmt.Errorf("client is nil")
+	}
+	sm := session.ServiceManager()
+
+	instance, err := sm.ReadServiceInstance(d.Id())
+	if err != nil {
+		if strings.Contains(err.Error(), "status code: 404") {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("name", instance.Name)
+	d.Set("space", instance.SpaceGUID)
+	d.Set("syslog_drain_url", instance.SyslogDrainURL)
+	d.Set("route_service_url", instance.RouteServiceURL)
+	d.Set("tags", instance.Tags)
+
+	return nil
+}
+
+func resourceUserProvidedServiceUpdate(d *schema.ResourceData, meta interface{}) error {
+
+	session := meta.(*cfapi.Session)
+	if session == nil {
+		return fmt.Errorf("client is nil")
+	}
+	sm := session.ServiceManager()
+
+	if _, err := sm.UpdateUserProvidedServiceInstance(
+		d.Id(),
+		d.Get("name").(string),
+		credentialsFromConfig(d.Get("credentials")),
+		d.Get("syslog_drain_url").(string),
+		d.Get("route_service_url").(string),
+		tagsFromConfig(d.Get("tags")),
+	); err != nil {
+		return err
+	}
+
+	return resourceUserProvidedServiceRead(d, meta)
+}
+
+func resourceUserProvidedServiceDelete(d *schema.ResourceData, meta interface{}) error {
+
+	session := meta.(*cfapi.Session)
+	if session == nil {
+		return fmt.Errorf("client is nil")
+	}
+	sm := session.ServiceManager()
+
+	if err := sm.DeleteServiceInstance(d.Id()); err != nil {
+		if !strings.Contains(err.Error(), "status code: 404") {
+			return err
+		}
+	}
+	return nil
+}
+
+func credentialsFromConfig(v interface{}) map[string]interface{} {
+	raw := v.(map[string]interface{})
+	credentials := make(map[string]interface{}, len(raw))
+	for k, v := range raw {
+		credentials[k] = v
+	}
+	return credentials
+}